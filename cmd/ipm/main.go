@@ -2,8 +2,7 @@
 package main
 
 import (
-	"path/filepath"
-
+	bundled "ipm/config"
 	"ipm/internal/ipm/cli"
 )
 
@@ -12,23 +11,10 @@ var cliCmd = "ipm"
 
 // main is the entry point for the IPM application.
 func main() {
-	// Get the executable path
-	// This function retrieves the path of the currently running executable.
-	// It is useful for determining the directory structure relative to the executable.
-	exePath := cli.GetExecutablePath()
-
-	// Get the directory of the executable
-	// This function extracts the directory part of the executable path.
-	exeDir := filepath.Dir(exePath)
-
-	// Define the configuration directory and schema file
-	// The configuration directory contains the configuration files for package managers.
-	// The schema file is used to validate the configuration files.
-	configDir := filepath.Join(exeDir, "config", "manager", "config")
-	schemaFile := filepath.Join(exeDir, "config", "manager", "schema", "manager.json")
-
 	// Initialize the CLI commands and structure
 	// This function sets up the command-line interface (CLI) commands and their structure.
-	// It uses the configuration directory and schema file to manage package manager configurations.
-	cli.InitializeCLI(configDir, schemaFile, cliCmd)
+	// The package manager configs, the schema used to validate them and the package name mapping
+	// are embedded in the binary, and the configs are overridden by the user, system or custom
+	// configuration directories.
+	cli.InitializeCLI(bundled.ManagerConfigs, bundled.ManagerSchema, bundled.PackageMapping, cliCmd)
 }
@@ -0,0 +1,61 @@
+// Package config provides the package manager configs and JSON schema embedded in the IPM application.
+package config
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// files holds the bundled package manager configs, the JSON schema used to validate them
+// and the package name mapping.
+//
+//go:embed manager/config/*.json manager/schema/manager.json manager/mapping/packages.json
+var files embed.FS
+
+// ManagerConfigs is the file system containing the bundled package manager configs,
+// with one "<manager>.json" file per package manager at its root.
+//
+// These configs are the lowest priority source of configs, overridden by the configs
+// found in the user, system or custom config directories.
+var ManagerConfigs = mustSub(files, "manager/config")
+
+// ManagerSchema is the bundled JSON schema used to validate the package manager configs.
+var ManagerSchema = mustReadFile(files, "manager/schema/manager.json")
+
+// PackageMapping is the bundled mapping of logical package names to the package ids of
+// each package manager, overridden by the user's mapping file.
+var PackageMapping = mustReadFile(files, "manager/mapping/packages.json")
+
+// mustSub returns the subtree of the embedded file system rooted at dir.
+//
+// Parameters:
+//   - fsys: The embedded file system.
+//   - dir: The directory to use as the root of the subtree.
+//
+// Returns:
+//   - fs.FS: The subtree rooted at dir. It panics if dir is not a valid path, which
+//     can only happen if the go:embed patterns above are changed.
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// mustReadFile reads a file from the embedded file system.
+//
+// Parameters:
+//   - fsys: The embedded file system.
+//   - name: The path to the file.
+//
+// Returns:
+//   - []byte: The content of the file. It panics if the file does not exist, which
+//     can only happen if the go:embed patterns above are changed.
+func mustReadFile(fsys fs.FS, name string) []byte {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,414 @@
+// Package cli provides command-line interface utilities for the IPM application.
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"ipm/internal/ipm/config"
+	"ipm/internal/ipm/manager"
+	"ipm/internal/ipm/utils"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Results of a command run against a package manager by the all command.
+const (
+	// resultOK is used when the command succeeded.
+	resultOK = "ok"
+	// resultFailed is used when the command failed.
+	resultFailed = "failed"
+	// resultSkipped is used when the command is not available for the package manager.
+	resultSkipped = "skipped"
+)
+
+// defaultJobs is the default number of package managers run concurrently by the all command.
+const defaultJobs = 4
+
+// managerResult represents the result of a command run against a package manager.
+//
+// Fields:
+//   - managerName: The name of the package manager.
+//   - result: The result of the command, one of the result constants.
+//   - err: The error returned by the command, or nil if it succeeded or was skipped.
+type managerResult struct {
+	managerName string
+	result      string
+	err         error
+}
+
+// AddAllCommand adds the all command to the root command.
+//
+// Parameters:
+//   - rootCmd: The root command to which the all command will be added.
+//   - paths: The locations of the configuration files for package managers.
+//
+// This function performs the following steps:
+//  1. Creates a new "all" command.
+//  2. Sets the command to run a command against every enabled and installed package manager.
+//  3. Adds the jobs flag bounding the number of package managers run concurrently, and the
+//     mapped-only flag skipping the package managers without a mapping entry for a package.
+//  4. Adds the "all" command to the root command.
+//
+// Example usage:
+//
+//	rootCmd := &cobra.Command{Use: "ipm"}
+//	AddAllCommand(rootCmd, paths)
+//
+// This function is useful on hosts mixing several package managers, e.g. to run
+// "ipm all upgrade-all" instead of "ipm apt upgrade-all", "ipm flatpak upgrade-all"
+// and so on.
+func AddAllCommand(rootCmd *cobra.Command, paths config.Paths) {
+	// Command to run a command against every package manager
+	var allCmd = &cobra.Command{
+		Use:   "all [command] [params]",
+		Short: "Execute a command for every enabled and installed package manager",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			jobs, _ := cmd.Flags().GetInt("jobs")
+			mappedOnly, _ := cmd.Flags().GetBool("mapped-only")
+			if jobs < 1 {
+				return fmt.Errorf("invalid number of jobs %d, must be at least 1", jobs)
+			}
+			return runAllManagers(paths, args[0], args[1:], dryRun, jobs, mappedOnly)
+		},
+	}
+
+	// Add flags to the all command
+	allCmd.Flags().IntP("jobs", "j", defaultJobs, "Number of package managers run concurrently, 1 to stream the output")
+	allCmd.Flags().Bool("mapped-only", false, "Skip the package managers without a mapping entry for a package, unless the command is read-only")
+
+	// Add the all command to the root command
+	rootCmd.AddCommand(allCmd)
+}
+
+// runAllManagers runs a command against every enabled and installed package manager.
+//
+// Parameters:
+//   - paths: The locations of the configuration files for package managers.
+//   - command: The name of the command to run, e.g. "upgrade-all".
+//   - params: The parameters passed to the command template.
+//   - dryRun: Whether to print the final commands without running them.
+//   - jobs: The maximum number of package managers run concurrently.
+//   - mappedOnly: Whether to skip the package managers without a mapping entry for a package,
+//     unless the command is read-only.
+//
+// Returns:
+//   - error: An error if the configuration files or the settings cannot be loaded, no package
+//     manager is enabled and installed, or the command failed for at least one package manager.
+//     A utils.CommandUnavailableError if none of them declares the command.
+//
+// This function performs the following steps:
+//  1. Validates and loads the configuration files of all package managers.
+//  2. Keeps the enabled package managers that are installed on the system, and checks that
+//     at least one of them declares the command.
+//  3. Runs the command against each of them, with the parameters mapped to the package ids
+//     of each package manager unless the command is search, continuing after failures.
+//     With mappedOnly, the commands that are not read-only skip the package managers that
+//     are not listed in the mapping for every package.
+//     With a single job, they run in sequence and their output is streamed. Otherwise, they
+//     run concurrently and the output of each one is printed as a labelled block once it is done.
+//  4. Prints a summary table with the result for each package manager.
+func runAllManagers(paths config.Paths, command string, params []string, dryRun bool, jobs int, mappedOnly bool) error {
+	// Validate and load the configuration files
+	if err := config.ValidateConfigFiles(paths); err != nil {
+		return err
+	}
+	configs, err := config.LoadConfigs(paths)
+	if err != nil {
+		return err
+	}
+	managerNames := installedManagers(configs)
+	if len(managerNames) == 0 {
+		return errors.New("no enabled package manager is installed")
+	}
+
+	// Fail on a command that no package manager declares, such as a misspelled command
+	declared := false
+	for _, managerName := range managerNames {
+		if commandTemplate, ok := configs[managerName].Commands[command]; ok && commandTemplate.IsAvailable() {
+			declared = true
+			break
+		}
+	}
+	if !declared {
+		return fmt.Errorf("%w: none of %s declares it", &utils.CommandUnavailableError{Command: command}, strings.Join(managerNames, ", "))
+	}
+
+	// Resolve the package names with the mapping, except the terms of the search command
+	mapping := utils.PackageMapping{}
+	if command != searchCommand {
+		if mapping, err = config.LoadMapping(paths); err != nil {
+			return err
+		}
+	}
+	settings, err := config.LoadSettings(paths)
+	if err != nil {
+		return err
+	}
+
+	// Run the command against each package manager
+	var results []managerResult
+	if jobs == 1 {
+		results = make([]managerResult, 0, len(managerNames))
+		for _, managerName := range managerNames {
+			fmt.Printf("==> %s\n", managerName)
+			results = append(results, runManagerCommand(managerName, configs[managerName], mapping, mappedOnly, command, params, dryRun, settings.Escalation, os.Stdout, os.Stderr))
+		}
+	} else {
+		results = runManagersConcurrently(managerNames, configs, mapping, mappedOnly, command, params, dryRun, settings.Escalation, jobs)
+	}
+
+	// Print the summary table and report the failures
+	printManagerResults(results)
+	var failed []string
+	for _, result := range results {
+		if result.result == resultFailed {
+			failed = append(failed, result.managerName)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("command %s failed for %s", command, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// installedManagers returns the enabled package managers installed on the system.
+//
+// Parameters:
+//   - configs: A map where the keys are package manager names and the values are their configs.
+//
+// Returns:
+//   - []string: The names of the enabled package managers whose binary is found and whose
+//     probe succeeds, sorted by name.
+func installedManagers(configs map[string]utils.CommandConfig) []string {
+	var managerNames []string
+	for managerName, managerConfig := range configs {
+		if !managerConfig.Enabled {
+			continue
+		}
+
+		// Use the default detection rules when the config declares none
+		detect := utils.DetectConfig{}
+		if managerConfig.Detect != nil {
+			detect = *managerConfig.Detect
+		}
+		if detect.IsDetected(managerName) {
+			managerNames = append(managerNames, managerName)
+		}
+	}
+	sort.Strings(managerNames)
+	return managerNames
+}
+
+// runManagersConcurrently runs a command against several package managers with a bounded worker pool.
+//
+// Parameters:
+//   - managerNames: The names of the package managers, sorted by name.
+//   - configs: A map where the keys are package manager names and the values are their configs.
+//   - mapping: The package name mapping resolving the parameters for each package manager.
+//   - mappedOnly: Whether to skip the package managers without a mapping entry for a package,
+//     unless the command is read-only.
+//   - command: The name of the command to run.
+//   - params: The parameters passed to the command template, as logical package names.
+//   - dryRun: Whether to print the final commands without running them.
+//   - escalation: The program running the commands that require root, from the settings.
+//   - jobs: The maximum number of package managers run concurrently.
+//
+// Returns:
+//   - []managerResult: The result of the command for each package manager, in the order of managerNames.
+//
+// This function performs the following steps:
+//  1. Runs the package managers concurrently with forEachManager, except those escalating
+//     the privileges of the command, which run one at a time unless in dry-run mode.
+//  2. Captures the standard output and standard error of each package manager separately.
+//  3. Prints the captured output as a labelled block as soon as a package manager is done.
+func runManagersConcurrently(managerNames []string, configs map[string]utils.CommandConfig, mapping utils.PackageMapping, mappedOnly bool, command string, params []string, dryRun bool, escalation string, jobs int) []managerResult {
+	results := make([]managerResult, len(managerNames))
+	var printMutex sync.Mutex
+	escalated := func(managerName string) bool {
+		return !dryRun && requiresEscalation(configs[managerName], command, escalation)
+	}
+	forEachManager(managerNames, configs, jobs, escalated, func(i int, managerName string) {
+		// Run the command with its output captured
+		var stdout, stderr bytes.Buffer
+		results[i] = runManagerCommand(managerName, configs[managerName], mapping, mappedOnly, command, params, dryRun, escalation, &stdout, &stderr)
+
+		// Print the captured output as a labelled block
+		printMutex.Lock()
+		defer printMutex.Unlock()
+		fmt.Printf("==> %s\n", managerName)
+		os.Stdout.Write(stdout.Bytes())
+		os.Stderr.Write(stderr.Bytes())
+	})
+	return results
+}
+
+// requiresEscalation reports whether a command of a package manager is run with an escalation program.
+//
+// Parameters:
+//   - managerConfig: The config of the package manager.
+//   - command: The name of the command to run.
+//   - escalation: The program running the commands that require root, from the settings.
+//
+// Returns:
+//   - bool: True if the command, or the install-version command run for the pinned
+//     packages of the install command, requires root and ipm escalates its privileges.
+func requiresEscalation(managerConfig utils.CommandConfig, command string, escalation string) bool {
+	if manager.RequiresEscalation(managerConfig.Commands[command], escalation) {
+		return true
+	}
+	return command == installCommand && manager.RequiresEscalation(managerConfig.Commands[installVersionCommand], escalation)
+}
+
+// forEachManager calls a function for several package managers with a bounded worker pool.
+//
+// Parameters:
+//   - managerNames: The names of the package managers.
+//   - configs: A map where the keys are package manager names and the values are their configs.
+//   - jobs: The maximum number of package managers processed concurrently.
+//   - escalated: The function reporting whether a package manager escalates its privileges,
+//     which may prompt for a password, or nil if none of them does.
+//   - fn: The function called with the index and the name of each package manager.
+//
+// This function performs the following steps:
+//  1. Creates one mutex per lock declared in the configs, so that package managers sharing
+//     a lock, such as apt and nala, are never processed concurrently, and one mutex shared
+//     by the package managers escalating their privileges, so that their password prompts
+//     do not interleave.
+//  2. Calls the function for each package manager in its own goroutine, after taking its
+//     lock, then the escalation mutex if it escalates its privileges, and only then one of
+//     the jobs slots, so that waiting package managers do not hold slots.
+//  3. Waits for all the calls to return.
+func forEachManager(managerNames []string, configs map[string]utils.CommandConfig, jobs int, escalated func(managerName string) bool, fn func(i int, managerName string)) {
+	// Create one mutex per lock shared by the package managers
+	locks := make(map[string]*sync.Mutex)
+	for _, managerName := range managerNames {
+		if lock := configs[managerName].Lock; lock != "" && locks[lock] == nil {
+			locks[lock] = &sync.Mutex{}
+		}
+	}
+
+	var escalationMutex sync.Mutex
+	slots := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, managerName := range managerNames {
+		wg.Add(1)
+		go func(i int, managerName string) {
+			defer wg.Done()
+
+			// Take the lock of the package manager and the escalation mutex, then a jobs slot
+			if lock := locks[configs[managerName].Lock]; lock != nil {
+				lock.Lock()
+				defer lock.Unlock()
+			}
+			if escalated != nil && escalated(managerName) {
+				escalationMutex.Lock()
+				defer escalationMutex.Unlock()
+			}
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			fn(i, managerName)
+		}(i, managerName)
+	}
+	wg.Wait()
+}
+
+// runManagerCommand runs a command against a single package manager.
+//
+// Parameters:
+//   - managerName: The name of the package manager.
+//   - managerConfig: The config of the package manager.
+//   - mapping: The package name mapping resolving the parameters for the package manager.
+//   - mappedOnly: Whether to skip the package manager if it has no mapping entry for a
+//     package, unless the command is read-only.
+//   - command: The name of the command to run.
+//   - params: The parameters passed to the command template, as logical package names.
+//   - dryRun: Whether to print the final commands without running them.
+//   - escalation: The program running the commands that require root, from the settings.
+//   - stdout: The writer receiving the standard output of the command and the messages of ipm.
+//   - stderr: The writer receiving the standard error of the command and the errors of ipm.
+//
+// Returns:
+//   - managerResult: The result of the command, skipped if the command is not available, or
+//     with mappedOnly if the command is not read-only and a package is not listed in the
+//     mapping for the package manager.
+func runManagerCommand(managerName string, managerConfig utils.CommandConfig, mapping utils.PackageMapping, mappedOnly bool, command string, params []string, dryRun bool, escalation string, stdout io.Writer, stderr io.Writer) managerResult {
+	// Skip the package managers that do not support the command
+	commandTemplate, ok := managerConfig.Commands[command]
+	if !ok || !commandTemplate.IsAvailable() {
+		fmt.Fprintf(stdout, "Command %s is not available for %s, skipping\n", command, managerName)
+		return managerResult{managerName: managerName, result: resultSkipped}
+	}
+
+	// Skip the package managers that do not list every package in the mapping if requested,
+	// unless the command is read-only, then resolve the package names
+	if mappedOnly && !commandTemplate.ReadOnly {
+		var unmapped []string
+		for _, param := range params {
+			if !mapping.Mapped(managerName, param) {
+				unmapped = append(unmapped, param)
+			}
+		}
+		if len(unmapped) > 0 {
+			fmt.Fprintf(stdout, "No mapping entry for %s with %s, skipping\n", strings.Join(unmapped, ", "), managerName)
+			return managerResult{managerName: managerName, result: resultSkipped}
+		}
+	}
+	params = mapping.ResolveAll(managerName, params)
+
+	// Check the number of packages declared by the command of the package manager
+	if err := commandTemplate.CheckArgs(params); err != nil {
+		err = fmt.Errorf("%s %v", command, err)
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return managerResult{managerName: managerName, result: resultFailed, err: err}
+	}
+
+	// Run the command and report the error without stopping
+	err := executeCommand(managerName, managerConfig, command, params, manager.ExecuteOptions{
+		Batch:      managerConfig.Batch,
+		DryRun:     dryRun,
+		Stdout:     stdout,
+		Stderr:     stderr,
+		Escalation: escalation,
+		ForbidRoot: managerConfig.ForbidRoot,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return managerResult{managerName: managerName, result: resultFailed, err: err}
+	}
+	return managerResult{managerName: managerName, result: resultOK}
+}
+
+// printManagerResults prints the summary table of a command run against several package managers.
+//
+// Parameters:
+//   - results: The result of the command for each package manager.
+//
+// Example output:
+//
+//	MANAGER  RESULT
+//	apt      ok
+//	npm      failed (exit 1)
+//	pip      skipped
+func printManagerResults(results []managerResult) {
+	fmt.Println()
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "MANAGER\tRESULT")
+	for _, result := range results {
+		status := result.result
+		if result.err != nil {
+			status = fmt.Sprintf("%s (exit %d)", status, exitCode(result.err))
+		}
+		fmt.Fprintf(writer, "%s\t%s\n", result.managerName, status)
+	}
+	writer.Flush()
+}
@@ -2,6 +2,8 @@
 package cli
 
 import (
+	"io/fs"
+	"ipm/internal/ipm/config"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -10,22 +12,43 @@ import (
 // InitializeCLI initializes the CLI commands and structure.
 //
 // Parameters:
-//   - configDir: The directory containing the configuration files for package managers.
-//   - schemaFile: The path to the JSON schema file used for validation.
+//   - embeddedConfigs: The file system containing the configuration files embedded in the application.
+//   - embeddedSchema: The JSON schema embedded in the application.
+//   - embeddedMapping: The package name mapping embedded in the application.
 //   - cliCmd: The name of the command-line interface (CLI) application.
 //
 // This function performs the following steps:
-//  1. Creates the root command for the CLI application.
-//  2. Updates the completion command.
-//  3. Updates the help command.
-//  4. Sets up the manager commands and their subcommands.
-//  5. Check required arguments for the validation command.
-//  6. Sets up the default manager commands based on the OS.
-//  7. Sets up dynamic manager commands based on the configuration files.
-//  8. Executes the root command.
-func InitializeCLI(configDir string, schemaFile string, cliCmd string) {
+//  1. Creates the root command for the CLI application and its global flags.
+//  2. Resolves the locations of the configuration files from the global flags.
+//  3. Updates the completion command.
+//  4. Updates the help command.
+//  5. Sets up the manager commands and their subcommands, and the detect, all, sync and export commands.
+//  6. Check required arguments for the validation command.
+//  7. Sets up the default manager commands for the package manager selected with the
+//     --manager flag, the IPM_MANAGER environment variable or the settings file, or
+//     detected based on the OS. If it cannot be set up, warns and reports the error only
+//     when a default command is run.
+//  8. Sets up dynamic manager commands based on the configuration files.
+//  9. Executes the root command.
+//  10. Reports any error and exits with the matching exit code.
+func InitializeCLI(embeddedConfigs fs.FS, embeddedSchema []byte, embeddedMapping []byte, cliCmd string) {
 	// Create the root command for the CLI application
-	var rootCmd = &cobra.Command{Use: cliCmd}
+	var rootCmd = &cobra.Command{
+		Use: cliCmd,
+		// Errors are reported by exitWithError once the command has run
+		SilenceErrors: true,
+		// Only show the usage for invalid arguments, not for failures while running
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			cmd.SilenceUsage = true
+		},
+	}
+
+	// Add the global flags shared by all commands
+	addGlobalFlags(rootCmd)
+
+	// Resolve the locations of the configuration files from the global flags
+	flags, args := parseGlobalFlags(rootCmd, os.Args)
+	paths := config.NewPaths(flags.configDir, flags.schemaFile, embeddedConfigs, embeddedSchema, embeddedMapping)
 
 	// Update the completion command
 	UpdateCompletionCommand(rootCmd)
@@ -34,22 +57,41 @@ func InitializeCLI(configDir string, schemaFile string, cliCmd string) {
 	UpdateHelpCommand(rootCmd)
 
 	// Set up the manager commands and their subcommands
-	SetupManagerCommands(rootCmd, configDir, schemaFile)
+	SetupManagerCommands(rootCmd, paths, flags)
+
+	// Add the detect command
+	AddDetectCommand(rootCmd, paths, flags)
+
+	// Add the all command
+	AddAllCommand(rootCmd, paths)
+
+	// Add the sync command
+	AddSyncCommand(rootCmd, paths, flags)
+
+	// Add the export command
+	AddExportCommand(rootCmd, paths)
 
 	// Check required arguments for the validation command
 	firstArg := ""
 	secondArg := ""
-	if len(os.Args) == 3 {
-		firstArg = os.Args[1]
-		secondArg = os.Args[2]
+	if len(args) == 3 {
+		firstArg = args[1]
+		secondArg = args[2]
 	}
 
-	// Set up the default manager commands based on the OS
-	SetupDefaultManagerCommands(rootCmd, configDir, schemaFile, os.Args, firstArg, secondArg)
-
 	// Set up dynamic manager commands based on the configuration files
-	SetupDynamicManagerCommands(rootCmd, configDir, schemaFile, os.Args, firstArg, secondArg)
+	if err := SetupDynamicManagerCommands(rootCmd, paths, args, firstArg, secondArg); err != nil {
+		exitWithError(err)
+	}
+
+	// Set up the default manager commands for the selected package manager, after the
+	// package manager commands so that an alias such as "apt" never shadows them
+	if err := SetupDefaultManagerCommands(rootCmd, paths, flags, args); err != nil {
+		addUnavailableDefaultCommands(rootCmd, err, args)
+	}
 
 	// Execute the root command
-	rootCmd.Execute()
+	if err := rootCmd.Execute(); err != nil {
+		exitWithError(err)
+	}
 }
@@ -2,91 +2,183 @@
 package cli
 
 import (
+	"fmt"
 	"ipm/internal/ipm/config"
-	"ipm/internal/ipm/manager"
 	"ipm/internal/ipm/utils"
-	"log"
-	"path/filepath"
+	"os"
+	"slices"
 	"sort"
 
 	"github.com/spf13/cobra"
 )
 
-// SetupDefaultManagerCommands sets up the default manager commands based on the OS.
+// Sources of the package manager used by the default commands, from the highest to the lowest priority.
+const (
+	// selectedByFlag is used when the package manager is selected with the --manager flag.
+	selectedByFlag = "flag"
+	// selectedByEnv is used when the package manager is selected with the IPM_MANAGER environment variable.
+	selectedByEnv = "environment"
+	// selectedBySettings is used when the package manager is the default one persisted in the settings file.
+	selectedBySettings = "settings"
+	// selectedByDetection is used when the package manager is detected based on the OS.
+	selectedByDetection = "detected"
+)
+
+// defaultCommandNames are the core commands of every package manager config, reporting why
+// the default commands are unavailable when the default package manager cannot be set up.
+var defaultCommandNames = []string{"info", "install", "list", "search", "uninstall", "update", "upgrade", "upgrade-all"}
+
+// managerSelection represents the package manager used by the default commands.
+//
+// Fields:
+//   - name: The name of the package manager, or an empty string if none is selected or detected.
+//   - source: How the package manager was selected, one of the selectedBy constants.
+//   - reason: A human-readable explanation of why the package manager was chosen.
+type managerSelection struct {
+	name   string
+	source string
+	reason string
+}
+
+// SetupDefaultManagerCommands sets up the default manager commands for the selected package manager.
 //
 // Parameters:
 //   - rootCmd: The root command to which the default manager commands will be added.
-//   - configDir: The directory containing the configuration files for package managers.
-//   - schemaFile: The path to the JSON schema file used for validation.
-//   - argsLength: The length of the command-line arguments.
-//   - firstArg: The first command-line argument.
-//   - secondArg: The second command-line argument.
+//   - paths: The locations of the configuration files for package managers and of the JSON schema file.
+//   - flags: The global flags, including the --manager flag and the IPM_MANAGER environment variable.
+//   - args: The program name followed by the positional command-line arguments.
+//
+// Returns:
+//   - error: An error if the settings file cannot be read, or if the config of the selected
+//     package manager cannot be found, validated or loaded.
 //
 // This function performs the following steps:
-//  1. Checks if the validate command is being run.
-//  2. Detects the default package manager based on the OS.
-//  3. Creates default commands for the detected package manager.
+//  1. Checks if a manager or detect command is being run, which does not need the default commands.
+//  2. Selects the package manager from the flags, the environment, the settings or the OS.
+//  3. Creates default commands for the selected package manager.
 //
 // Example usage:
 //
 //	rootCmd := &cobra.Command{Use: "ipm"}
-//	SetupDefaultManagerCommands(rootCmd, "/path/to/configDir", "/path/to/schemaFile", os.Args, os.Args[1], os.Args[2])
-//
-// This function is useful for setting up default commands for the package manager
-// detected based on the OS. It ensures that the default package manager commands
-// are available in the CLI.
-func SetupDefaultManagerCommands(rootCmd *cobra.Command, configDir string, schemaFile string, argsLength []string, firstArg string, secondArg string) {
-	// Check if the validate command is being run
-	if len(argsLength) < 3 || firstArg != "manager" || secondArg != "validate" {
-		// Detect default package manager based on the OS
-		defaultManager := utils.DetectDefaultPackageManager()
-		if defaultManager != "" {
-			createDefaultCommands(rootCmd, defaultManager, configDir, schemaFile)
-		}
+//	SetupDefaultManagerCommands(rootCmd, paths, flags, args)
+//
+// This function is useful for setting up commands such as "ipm install" for the package
+// manager picked by the user, or detected based on the OS when none is picked. Manager and
+// detect commands are skipped so that a stale default can still be inspected with "ipm detect"
+// and changed with "ipm manager default".
+func SetupDefaultManagerCommands(rootCmd *cobra.Command, paths config.Paths, flags globalFlags, args []string) error {
+	// Check if a command that does not need the default commands is being run
+	if len(args) > 1 && (args[1] == "manager" || args[1] == "detect") {
+		return nil
+	}
+
+	// Select the package manager of the default commands
+	selection, err := selectDefaultManager(flags, paths)
+	if err != nil {
+		return err
+	}
+	if selection.name == "" {
+		return nil
 	}
+	return createDefaultCommands(rootCmd, selection, paths)
+}
+
+// selectDefaultManager selects the package manager used by the default commands.
+//
+// Parameters:
+//   - flags: The global flags, including the --manager flag and the IPM_MANAGER environment variable.
+//   - paths: The locations of the configuration files, including the settings file.
+//
+// Returns:
+//   - managerSelection: The selected package manager and how it was selected.
+//   - error: An error if the settings file or the configuration files cannot be read.
+//
+// This function performs the following steps:
+//  1. Uses the --manager flag if it is set.
+//  2. Otherwise, uses the IPM_MANAGER environment variable if it is set.
+//  3. Otherwise, uses the default package manager persisted in the settings file if it is set.
+//  4. Otherwise, detects the package manager from the detection rules of the configs.
+func selectDefaultManager(flags globalFlags, paths config.Paths) (managerSelection, error) {
+	if flags.manager != "" {
+		return managerSelection{name: flags.manager, source: selectedByFlag, reason: "selected with the --manager flag"}, nil
+	}
+	if flags.managerEnv != "" {
+		return managerSelection{name: flags.managerEnv, source: selectedByEnv, reason: "selected with the " + managerEnv + " environment variable"}, nil
+	}
+
+	// Read the default package manager from the settings file
+	settings, err := config.LoadSettings(paths)
+	if err != nil {
+		return managerSelection{}, err
+	}
+	if settings.Default != "" {
+		return managerSelection{name: settings.Default, source: selectedBySettings, reason: "default set in " + paths.SettingsFile}, nil
+	}
+
+	// Detect the default package manager from the detection rules of the configs
+	configs, err := config.LoadConfigs(paths)
+	if err != nil {
+		return managerSelection{}, err
+	}
+	detection := utils.DetectDefaultPackageManager(configs)
+	return managerSelection{name: detection.ManagerName, source: selectedByDetection, reason: detection.Reason}, nil
 }
 
 // createDefaultCommands creates default commands for the specified package manager.
 //
 // Parameters:
 //   - rootCmd: The root command to which the default commands will be added.
-//   - managerName: The name of the package manager.
-//   - configDir: The directory containing the configuration files for package managers.
-//   - schemaFile: The path to the JSON schema file used for validation.
+//   - selection: The package manager and how it was selected.
+//   - paths: The locations of the configuration files for package managers and of the JSON schema file.
+//
+// Returns:
+//   - error: An error if the configuration files fail validation, the config cannot be loaded,
+//     or the package manager is disabled and was selected explicitly.
 //
 // This function performs the following steps:
 //  1. Validates JSON files against the schema.
 //  2. Reads the commands from the JSON file.
 //  3. Unmarshals the config data.
-//  4. Checks if the commands are enabled.
-//  5. Adds the commands to the root command.
+//  4. Checks if the commands are enabled, failing if the package manager was selected with
+//     the --manager flag, the IPM_MANAGER environment variable or the settings file, and
+//     adding no command if it was detected.
+//  5. Adds the commands to the root command, with the everywhere flag for the search command,
+//     except the commands and aliases named after a command of ipm.
 //
 // Example usage:
 //
 //	rootCmd := &cobra.Command{Use: "ipm"}
-//	createDefaultCommands(rootCmd, "defaultManager", "/path/to/configDir", "/path/to/schemaFile")
+//	createDefaultCommands(rootCmd, managerSelection{name: "apt", source: selectedByFlag}, paths)
 //
 // This function is useful for creating default commands for a specified package manager.
 // It reads the configuration from a JSON file, validates it against the schema, and
 // adds the commands to the root command if they are enabled.
-func createDefaultCommands(rootCmd *cobra.Command, managerName string, configDir string, schemaFile string) {
+func createDefaultCommands(rootCmd *cobra.Command, selection managerSelection, paths config.Paths) error {
+	managerName := selection.name
+
 	// Validate JSON files against the schema
-	if err := utils.ValidateJSONFiles(schemaFile, configDir); err != nil {
-		log.Fatalf("%v", err)
+	if err := config.ValidateConfigFiles(paths); err != nil {
+		return err
 	}
 
-	// Read the commands from the JSON file
-	configFile := filepath.Join(configDir, managerName+".json")
-
-	// Read the config file
-	data := config.ReadConfigFile(configFile)
+	// Find the JSON file of the package manager
+	configFile, err := paths.ConfigFile(managerName)
+	if err != nil {
+		return err
+	}
 
-	// Unmarshal the config data
-	config := config.UnmarshalConfig(data, configFile)
+	// Load the config file
+	config, err := config.LoadConfig(configFile)
+	if err != nil {
+		return err
+	}
 
-	// Check if the commands are enabled
+	// Check if the commands are enabled, reporting a disabled package manager picked by the user
 	if !config.Enabled {
-		return
+		if selection.source == selectedByDetection {
+			return nil
+		}
+		return fmt.Errorf("package manager %s is disabled, enable it with \"ipm manager enable %s\"", managerName, managerName)
 	}
 
 	// Extract and sort the keys
@@ -98,13 +190,88 @@ func createDefaultCommands(rootCmd *cobra.Command, managerName string, configDir
 
 	// Create default commands for each command in the JSON file
 	for _, command := range keys {
+		// Skip the install-version command, which needs a version to pin the package to
+		if command == installVersionCommand {
+			continue
+		}
+
+		// Skip the commands named after a command of ipm, such as "sync" or "export"
+		if existing, _, err := rootCmd.Find([]string{command}); err == nil && existing != rootCmd {
+			continue
+		}
+
 		cmd := &cobra.Command{
-			Use:   command + " [params]",
-			Short: "Execute " + command + " command for " + managerName,
-			Run: func(cmd *cobra.Command, args []string) {
-				manager.ExecuteCommandTemplate(command, config.Commands[command], args)
+			Use:     commandUse(command, config.Commands[command]),
+			Short:   commandShort(config.Commands[command], "Execute "+command+" command for "+managerName),
+			Args:    commandArgs(config.Commands[command]),
+			Aliases: availableAliases(rootCmd, config.Commands[command].Aliases),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runConfigCommand(cmd, paths, managerName, config, command, args)
 			},
 		}
+		addOutputFlag(cmd, command, config)
+		addEverywhereFlag(cmd, command)
 		rootCmd.AddCommand(cmd)
 	}
+	return nil
+}
+
+// availableAliases returns the aliases of a default command that do not clash with a command of ipm.
+//
+// The default commands are added after the package manager commands, so that an alias
+// named after a package manager, such as "apt", is dropped instead of shadowing it.
+//
+// Parameters:
+//   - rootCmd: The root command holding the commands of ipm and of the package managers.
+//   - aliases: The aliases of the command declared in the config.
+//
+// Returns:
+//   - []string: The aliases that are not the name or alias of a command of ipm or of a
+//     package manager.
+func availableAliases(rootCmd *cobra.Command, aliases []string) []string {
+	var available []string
+	for _, alias := range aliases {
+		if existing, _, err := rootCmd.Find([]string{alias}); err == nil && existing != rootCmd {
+			continue
+		}
+		available = append(available, alias)
+	}
+	return available
+}
+
+// addUnavailableDefaultCommands adds default commands that report why the default package
+// manager cannot be set up.
+//
+// Parameters:
+//   - rootCmd: The root command to which the default commands will be added.
+//   - err: The error returned while setting up the default commands, e.g. a
+//     utils.ConfigNotFoundError for a misspelled IPM_MANAGER.
+//   - args: The program name followed by the positional command-line arguments.
+//
+// This function performs the following steps:
+//  1. Prints a warning with the error, without failing the other commands, unless a
+//     default command is run, which reports the error itself.
+//  2. Adds a hidden command for each core command, returning the error when it runs, so
+//     that "ipm install" reports the error with its exit code instead of an unknown command.
+//
+// Example usage:
+//
+//	if err := SetupDefaultManagerCommands(rootCmd, paths, flags, args); err != nil {
+//		addUnavailableDefaultCommands(rootCmd, err, args)
+//	}
+func addUnavailableDefaultCommands(rootCmd *cobra.Command, err error, args []string) {
+	if len(args) < 2 || !slices.Contains(defaultCommandNames, args[1]) {
+		fmt.Fprintf(os.Stderr, "Warning: the default commands are unavailable: %v\n", err)
+	}
+	for _, command := range defaultCommandNames {
+		rootCmd.AddCommand(&cobra.Command{
+			Use:                command,
+			Short:              "Execute " + command + " command for the default package manager",
+			Hidden:             true,
+			DisableFlagParsing: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return err
+			},
+		})
+	}
 }
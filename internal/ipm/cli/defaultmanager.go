@@ -0,0 +1,72 @@
+// Package cli provides command-line interface utilities for the IPM application.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"ipm/internal/ipm/config"
+
+	"github.com/spf13/cobra"
+)
+
+// AddDefaultCommand adds the default command to the manager command.
+//
+// Parameters:
+//   - managerCmd: The manager command to which the default command will be added.
+//   - paths: The locations of the configuration files for package managers and of the settings file.
+//   - flags: The global flags, used to show the package manager of the default commands.
+//
+// This function performs the following steps:
+//  1. Creates a new "default" command.
+//  2. Sets the command to show, set or clear the package manager of the default commands.
+//  3. Adds the "default" command to the manager command.
+//
+// Example usage:
+//
+//	managerCmd := &cobra.Command{Use: "manager"}
+//	AddDefaultCommand(managerCmd, paths, flags)
+//
+// This function is useful for choosing the package manager used by commands such as
+// "ipm install", instead of the first package manager detected on the system.
+func AddDefaultCommand(managerCmd *cobra.Command, paths config.Paths, flags globalFlags) {
+	// Command to show, set or clear the default package manager
+	var defaultCmd = &cobra.Command{
+		Use:   "default [manager]",
+		Short: "Show or set the package manager used by the default commands",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			unset, _ := cmd.Flags().GetBool("unset")
+
+			// Clear the default package manager if the unset flag is passed
+			if unset {
+				if len(args) > 0 {
+					return errors.New("--unset does not accept a manager")
+				}
+				return config.SetDefaultManager("", paths)
+			}
+
+			// Set the default package manager if one is passed
+			if len(args) == 1 {
+				return config.SetDefaultManager(args[0], paths)
+			}
+
+			// Otherwise, show the selected package manager and how it was selected
+			selection, err := selectDefaultManager(flags, paths)
+			if err != nil {
+				return err
+			}
+			if selection.name == "" {
+				fmt.Println("No package manager detected")
+				return nil
+			}
+			fmt.Printf("%s (%s)\n", selection.name, selection.source)
+			return nil
+		},
+	}
+
+	// Add flags to the default command
+	defaultCmd.Flags().Bool("unset", false, "Clear the default package manager and detect it based on the OS")
+
+	// Add the default command to the manager command
+	managerCmd.AddCommand(defaultCmd)
+}
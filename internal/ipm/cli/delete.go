@@ -11,7 +11,7 @@ import (
 //
 // Parameters:
 //   - managerCmd: The manager command to which the delete command will be added.
-//   - configDir: The directory containing the configuration files for package managers.
+//   - paths: The locations of the configuration files for package managers.
 //
 // This function performs the following steps:
 //  1. Creates a new "delete" command.
@@ -21,19 +21,19 @@ import (
 // Example usage:
 //
 //	managerCmd := &cobra.Command{Use: "manager"}
-//	AddDeleteCommand(managerCmd, "/path/to/configDir")
+//	AddDeleteCommand(managerCmd, paths)
 //
 // This function is useful for deleting a configuration file for a specified
 // package manager. It removes the configuration file from the specified directory
 // and prints the result.
-func AddDeleteCommand(managerCmd *cobra.Command, configDir string) {
+func AddDeleteCommand(managerCmd *cobra.Command, paths config.Paths) {
 	// Command to delete a package manager config
 	var deleteCmd = &cobra.Command{
 		Use:   "delete [manager]",
 		Short: "Delete a package manager config",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			config.DeleteManagerConfig(args[0], configDir)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return config.DeleteManagerConfig(args[0], paths)
 		},
 	}
 
@@ -0,0 +1,258 @@
+// Package cli provides command-line interface utilities for the IPM application.
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"ipm/internal/ipm/config"
+	"ipm/internal/ipm/utils"
+	"os"
+	"runtime"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// detectReport represents what ipm sees on the host, as printed by the detect command.
+//
+// Fields:
+//   - Default: The package manager used by the default commands and why it was chosen.
+//   - OS: The operating system, as reported by runtime.GOOS.
+//   - Distribution: The Linux distribution identified by os-release, or nil if it is unknown.
+//   - Managers: The status of every known package manager, sorted by name.
+type detectReport struct {
+	Default      defaultStatus    `json:"default"`
+	OS           string           `json:"os"`
+	Distribution *utils.OSRelease `json:"distribution,omitempty"`
+	Managers     []managerStatus  `json:"managers"`
+}
+
+// defaultStatus represents the package manager used by the default commands.
+//
+// Fields:
+//   - Manager: The name of the package manager, or an empty string if none is detected.
+//   - SelectedBy: How the package manager was selected, one of the selectedBy constants.
+//   - Reason: A human-readable explanation of why the package manager was chosen.
+type defaultStatus struct {
+	Manager    string `json:"manager"`
+	SelectedBy string `json:"selectedBy"`
+	Reason     string `json:"reason"`
+}
+
+// managerStatus represents the status of a package manager on the host.
+//
+// Fields:
+//   - Name: The name of the package manager.
+//   - Installed: Whether the binary of the package manager is found and its probe succeeds.
+//   - Path: The path to the binary of the package manager, if it is found.
+//   - Version: The version printed by the version command of the package manager, if any.
+//   - ConfigExists: Whether a config exists for the package manager.
+//   - Config: The source of the config, e.g. "user" or "embedded", if it exists.
+//   - Enabled: Whether the config of the package manager is enabled.
+//   - Default: Whether the package manager is used by the default commands.
+type managerStatus struct {
+	Name         string `json:"name"`
+	Installed    bool   `json:"installed"`
+	Path         string `json:"path,omitempty"`
+	Version      string `json:"version,omitempty"`
+	ConfigExists bool   `json:"configExists"`
+	Config       string `json:"config,omitempty"`
+	Enabled      bool   `json:"enabled"`
+	Default      bool   `json:"default"`
+}
+
+// AddDetectCommand adds the detect command to the root command.
+//
+// Parameters:
+//   - rootCmd: The root command to which the detect command will be added.
+//   - paths: The locations of the configuration files for package managers and of the settings file.
+//   - flags: The global flags, including the --manager flag and the IPM_MANAGER environment variable.
+//
+// This function performs the following steps:
+//  1. Creates a new "detect" command.
+//  2. Sets the command to show the package manager of the default commands, why it was chosen
+//     and the status of every known package manager, as text or as JSON.
+//  3. Adds the "detect" command to the root command.
+//
+// Example usage:
+//
+//	rootCmd := &cobra.Command{Use: "ipm"}
+//	AddDetectCommand(rootCmd, paths, flags)
+//
+// This function is useful for troubleshooting which package manager commands such as
+// "ipm install" run, e.g. on hosts with several package managers installed.
+func AddDetectCommand(rootCmd *cobra.Command, paths config.Paths, flags globalFlags) {
+	// Command to show what ipm detects on the host
+	var detectCmd = &cobra.Command{
+		Use:   "detect",
+		Short: "Show the package managers detected on the system",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			report, err := buildDetectReport(paths, flags)
+			if err != nil {
+				return err
+			}
+
+			// Print the report as JSON if the json flag is passed
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			printDetectReport(report)
+			return nil
+		},
+	}
+
+	// Add flags to the detect command
+	detectCmd.Flags().Bool("json", false, "Print the report as JSON")
+
+	// Add the detect command to the root command
+	rootCmd.AddCommand(detectCmd)
+}
+
+// buildDetectReport runs the detection logic for every known package manager.
+//
+// Parameters:
+//   - paths: The locations of the configuration files for package managers and of the settings file.
+//   - flags: The global flags, including the --manager flag and the IPM_MANAGER environment variable.
+//
+// Returns:
+//   - detectReport: The package manager of the default commands and the status of every package manager.
+//   - error: An error if the settings file or the configuration files cannot be read.
+//
+// This function performs the following steps:
+//  1. Selects the package manager of the default commands.
+//  2. Reads the Linux distribution from os-release.
+//  3. For every config, looks up the binary, runs the probe and version commands and reads the enabled status.
+//  4. Adds the selected package manager if it has no config.
+func buildDetectReport(paths config.Paths, flags globalFlags) (detectReport, error) {
+	// Select the package manager of the default commands
+	selection, err := selectDefaultManager(flags, paths)
+	if err != nil {
+		return detectReport{}, err
+	}
+	report := detectReport{
+		Default: defaultStatus{Manager: selection.name, SelectedBy: selection.source, Reason: selection.reason},
+		OS:      runtime.GOOS,
+	}
+
+	// Read the Linux distribution
+	if runtime.GOOS == "linux" {
+		if release, err := utils.ReadOSRelease(); err == nil {
+			report.Distribution = &release
+		}
+	}
+
+	// Get the config file of each package manager
+	configFiles, err := paths.ConfigFiles()
+	if err != nil {
+		return detectReport{}, fmt.Errorf("failed to read manager files: %v", err)
+	}
+
+	// Run the detection logic for each package manager
+	selectedFound := false
+	for _, configFile := range configFiles {
+		managerConfig, err := config.LoadConfig(configFile)
+		if err != nil {
+			return detectReport{}, err
+		}
+
+		// Use the default detection rules when the config declares none
+		detect := utils.DetectConfig{}
+		if managerConfig.Detect != nil {
+			detect = *managerConfig.Detect
+		}
+
+		status := managerStatus{
+			Name:         configFile.ManagerName,
+			Installed:    detect.IsDetected(configFile.ManagerName),
+			Path:         detect.BinaryPath(configFile.ManagerName),
+			ConfigExists: true,
+			Config:       configFile.Source.Name,
+			Enabled:      managerConfig.Enabled,
+			Default:      configFile.ManagerName == selection.name,
+		}
+		if status.Path != "" {
+			status.Version = detect.DetectVersion()
+		}
+		selectedFound = selectedFound || status.Default
+		report.Managers = append(report.Managers, status)
+	}
+
+	// Add the selected package manager if it has no config
+	if selection.name != "" && !selectedFound {
+		detect := utils.DetectConfig{}
+		report.Managers = append(report.Managers, managerStatus{
+			Name:      selection.name,
+			Installed: detect.IsDetected(selection.name),
+			Path:      detect.BinaryPath(selection.name),
+			Default:   true,
+		})
+	}
+	return report, nil
+}
+
+// printDetectReport prints the detect report as text.
+//
+// Parameters:
+//   - report: The report to print.
+//
+// Example output:
+//
+//	Manager:      apt
+//	Selected by:  detected
+//	Reason:       native package manager of debian (ID in os-release)
+//	OS:           linux
+//	Distribution: debian
+//
+//	NAME  INSTALLED  CONFIG    ENABLED  PATH          VERSION
+//	apt   yes        embedded  yes      /usr/bin/apt  apt 2.6.1 (amd64)
+func printDetectReport(report detectReport) {
+	// Print the selected package manager and why it was chosen
+	managerName := report.Default.Manager
+	if managerName == "" {
+		managerName = "none"
+	}
+	fmt.Printf("Manager:      %s\n", managerName)
+	fmt.Printf("Selected by:  %s\n", report.Default.SelectedBy)
+	fmt.Printf("Reason:       %s\n", report.Default.Reason)
+
+	// Print the operating system and the Linux distribution used for the detection
+	fmt.Printf("OS:           %s\n", report.OS)
+	if report.Distribution != nil {
+		fmt.Printf("Distribution: %s\n", report.Distribution)
+	}
+	fmt.Println()
+
+	// Print the status of every package manager
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tINSTALLED\tCONFIG\tENABLED\tPATH\tVERSION")
+	for _, status := range report.Managers {
+		configSource := status.Config
+		if !status.ConfigExists {
+			configSource = "missing"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			status.Name, yesNo(status.Installed), configSource, yesNo(status.Enabled), status.Path, status.Version)
+	}
+	writer.Flush()
+}
+
+// yesNo formats a boolean for the text output of the commands.
+//
+// Parameters:
+//   - value: The boolean to format.
+//
+// Returns:
+//   - string: "yes" if the value is true, "no" otherwise.
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
@@ -11,7 +11,7 @@ import (
 //
 // Parameters:
 //   - managerCmd: The manager command to which the disable command will be added.
-//   - configDir: The directory containing the configuration files for package managers.
+//   - paths: The locations of the configuration files for package managers.
 //
 // This function performs the following steps:
 //  1. Creates a new "disable" command.
@@ -21,18 +21,18 @@ import (
 // Example usage:
 //
 //	managerCmd := &cobra.Command{Use: "manager"}
-//	AddDisableCommand(managerCmd, "/path/to/configDir")
+//	AddDisableCommand(managerCmd, paths)
 //
 // This function is useful for disabling a specified package manager. It updates the
 // configuration to mark the package manager as disabled and prints the result.
-func AddDisableCommand(managerCmd *cobra.Command, configDir string) {
+func AddDisableCommand(managerCmd *cobra.Command, paths config.Paths) {
 	// Command to disable a package manager
 	var disableCmd = &cobra.Command{
 		Use:   "disable [manager]",
 		Short: "Disable a package manager",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			config.SetManager(args[0], configDir, false)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return config.SetManager(args[0], paths, false)
 		},
 	}
 
@@ -2,13 +2,10 @@
 package cli
 
 import (
+	"fmt"
 	"ipm/internal/ipm/config"
-	"ipm/internal/ipm/manager"
 	"ipm/internal/ipm/utils"
-	"log"
-	"path/filepath"
 	"sort"
-	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -17,84 +14,96 @@ import (
 //
 // Parameters:
 //   - rootCmd: The root command to which the default manager commands will be added.
-//   - configDir: The directory containing the configuration files for package managers.
-//   - schemaFile: The path to the JSON schema file used for validation.
+//   - paths: The locations of the configuration files for package managers and of the JSON schema file.
 //   - argsLength: The length of the command-line arguments.
 //   - firstArg: The first command-line argument.
 //   - secondArg: The second command-line argument.
 //
+// Returns:
+//   - error: An error if the configuration files cannot be listed, validated or loaded.
+//
 // This function performs the following steps:
 //  1. Checks if the validate command is being run.
-//  2. Dynamically reads manager names from the config directory.
+//  2. Dynamically reads manager names from the config sources.
 //  3. Creates commands for each detected package manager.
 //
 // Example usage:
 //
 //	rootCmd := &cobra.Command{Use: "ipm"}
-//	SetupDynamicManagerCommands(rootCmd, "/path/to/configDir", "/path/to/schemaFile", os.Args, os.Args[1], os.Args[2])
+//	SetupDynamicManagerCommands(rootCmd, paths, os.Args, os.Args[1], os.Args[2])
 //
 // This function is useful for dynamically setting up commands for package managers
 // based on the configuration files present in the config directory. It ensures that
 // the commands for each package manager are available in the CLI.
-func SetupDynamicManagerCommands(rootCmd *cobra.Command, configDir string, schemaFile string, argsLength []string, firstArg string, secondArg string) {
+func SetupDynamicManagerCommands(rootCmd *cobra.Command, paths config.Paths, argsLength []string, firstArg string, secondArg string) error {
 	// Check if the validate command is being run
 	if len(argsLength) < 3 || firstArg != "manager" || secondArg != "validate" {
-		// Dynamically read manager names from the config directory
-		managerFiles, err := filepath.Glob(filepath.Join(configDir, "*.json"))
+		// Dynamically read manager names from the config sources
+		managerFiles, err := paths.ConfigFiles()
 		if err != nil {
-			log.Fatalf("Failed to read manager files: %v", err)
+			return fmt.Errorf("failed to read manager files: %v", err)
 		}
 
 		// Iterate over each manager file and create commands
 		for _, managerFile := range managerFiles {
-			managerName := strings.TrimSuffix(filepath.Base(managerFile), ".json")
-			managerCmd := createManagerCommand(managerName, configDir, schemaFile)
+			managerCmd, err := createManagerCommand(managerFile.ManagerName, paths)
+			if err != nil {
+				return err
+			}
 			if managerCmd != nil {
 				rootCmd.AddCommand(managerCmd)
 			}
 		}
 	}
+	return nil
 }
 
 // createManagerCommand creates a command for the specified package manager.
 //
 // Parameters:
 //   - managerName: The name of the package manager.
-//   - configDir: The directory containing the configuration files for package managers.
-//   - schemaFile: The path to the JSON schema file used for validation.
+//   - paths: The locations of the configuration files for package managers and of the JSON schema file.
+//
+// Returns:
+//   - *cobra.Command: The command for the package manager, or nil if the package manager is disabled.
+//   - error: An error if the configuration files fail validation or the config cannot be loaded.
 //
 // This function performs the following steps:
 //  1. Validates JSON files against the schema.
 //  2. Reads the commands from the JSON file.
 //  3. Unmarshals the config data.
 //  4. Checks if the commands are enabled.
-//  5. Creates and returns a cobra.Command for the package manager.
+//  5. Creates and returns a cobra.Command for the package manager, with a subcommand for
+//     each command validating the number of packages passed to it.
 //
 // Example usage:
 //
-//	managerCmd := createManagerCommand("apt", "/path/to/configDir", "/path/to/schemaFile")
+//	managerCmd, err := createManagerCommand("apt", paths)
 //
 // This function is useful for creating a command for a specified package manager.
 // It reads the configuration from a JSON file, validates it against the schema, and
 // creates a cobra.Command if the commands are enabled.
-func createManagerCommand(managerName string, configDir string, schemaFile string) *cobra.Command {
+func createManagerCommand(managerName string, paths config.Paths) (*cobra.Command, error) {
 	// Validate JSON files against the schema
-	if err := utils.ValidateJSONFiles(schemaFile, configDir); err != nil {
-		log.Fatalf("%v", err)
+	if err := config.ValidateConfigFiles(paths); err != nil {
+		return nil, err
 	}
 
-	// Read the commands from the JSON file
-	configFile := filepath.Join(configDir, managerName+".json")
-
-	// Read the config file
-	data := config.ReadConfigFile(configFile)
+	// Find the JSON file of the package manager
+	configFile, err := paths.ConfigFile(managerName)
+	if err != nil {
+		return nil, err
+	}
 
-	// Unmarshal the config data
-	config := config.UnmarshalConfig(data, configFile)
+	// Load the config file
+	config, err := config.LoadConfig(configFile)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if the commands are enabled
 	if !config.Enabled {
-		return nil
+		return nil, nil
 	}
 
 	// Extract and sort the keys
@@ -112,15 +121,80 @@ func createManagerCommand(managerName string, configDir string, schemaFile strin
 
 	// Create default commands for each command in the JSON file
 	for _, command := range keys {
+		// Skip the install-version command, which needs a version to pin the package to
+		if command == installVersionCommand {
+			continue
+		}
+
 		cmd := &cobra.Command{
-			Use:   command + " [params]",
-			Short: "Execute " + command + " command for " + managerName + " package manager",
-			Run: func(cmd *cobra.Command, args []string) {
-				manager.ExecuteCommandTemplate(command, config.Commands[command], args)
+			Use:     commandUse(command, config.Commands[command]),
+			Short:   commandShort(config.Commands[command], "Execute "+command+" command for "+managerName+" package manager"),
+			Args:    commandArgs(config.Commands[command]),
+			Aliases: config.Commands[command].Aliases,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runConfigCommand(cmd, paths, managerName, config, command, args)
 			},
 		}
+		addOutputFlag(cmd, command, config)
+		addEverywhereFlag(cmd, command)
 		managerCmd.AddCommand(cmd)
 	}
 
-	return managerCmd
+	return managerCmd, nil
+}
+
+// commandShort returns the short description of a command shown in the help.
+//
+// Parameters:
+//   - commandTemplate: The command declared in the config.
+//   - fallback: The description used when the config does not describe the command.
+//
+// Returns:
+//   - string: The description of the command in the config, or the fallback description.
+//
+// Example usage:
+//
+//	short := commandShort(config.Commands["autoremove"], "Execute autoremove command for apt")
+func commandShort(commandTemplate utils.Command, fallback string) string {
+	if commandTemplate.Description != "" {
+		return commandTemplate.Description
+	}
+	return fallback
+}
+
+// commandUse returns the usage line of a command, naming the packages it accepts.
+//
+// Parameters:
+//   - command: The name of the command in the config.
+//   - commandTemplate: The command declared in the config.
+//
+// Returns:
+//   - string: The usage line of the command, e.g. "install <packages>" or "update".
+func commandUse(command string, commandTemplate utils.Command) string {
+	switch commandTemplate.ArgsSpec() {
+	case utils.ArgsOne:
+		return command + " <package>"
+	case utils.ArgsMany:
+		return command + " <packages>"
+	case utils.ArgsOptional:
+		return command + " [packages]"
+	}
+	return command
+}
+
+// commandArgs returns the validator of the packages passed to a command.
+//
+// Parameters:
+//   - commandTemplate: The command declared in the config.
+//
+// Returns:
+//   - cobra.PositionalArgs: A validator failing before the command runs if the number of
+//     packages does not match the args of the command, e.g. "ipm install" without a package.
+func commandArgs(commandTemplate utils.Command) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := commandTemplate.CheckArgs(args); err != nil {
+			return fmt.Errorf("%s %v", cmd.CommandPath(), err)
+		}
+		return nil
+	}
 }
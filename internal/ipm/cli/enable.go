@@ -11,7 +11,7 @@ import (
 //
 // Parameters:
 //   - managerCmd: The manager command to which the enable command will be added.
-//   - configDir: The directory containing the configuration files for package managers.
+//   - paths: The locations of the configuration files for package managers.
 //
 // This function performs the following steps:
 //  1. Creates a new "enable" command.
@@ -21,18 +21,18 @@ import (
 // Example usage:
 //
 //	managerCmd := &cobra.Command{Use: "manager"}
-//	AddEnableCommand(managerCmd, "/path/to/configDir")
+//	AddEnableCommand(managerCmd, paths)
 //
 // This function is useful for enabling a specified package manager. It updates the
 // configuration to mark the package manager as enabled and prints the result.
-func AddEnableCommand(managerCmd *cobra.Command, configDir string) {
+func AddEnableCommand(managerCmd *cobra.Command, paths config.Paths) {
 	// Command to enable a package manager
 	var enableCmd = &cobra.Command{
 		Use:   "enable [manager]",
 		Short: "Enable a package manager",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			config.SetManager(args[0], configDir, true)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return config.SetManager(args[0], paths, true)
 		},
 	}
 
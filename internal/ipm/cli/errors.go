@@ -0,0 +1,106 @@
+// Package cli provides command-line interface utilities for the IPM application.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"ipm/internal/ipm/utils"
+)
+
+// Exit codes used by ipm for its own errors.
+//
+// When a package manager command fails, ipm exits with the exit status of the package
+// manager instead, so that scripts can tell its failures apart. The codes below follow
+// the conventions of sysexits.h and of POSIX shells, and are kept stable across releases.
+const (
+	// exitGeneralError is used for errors without a more specific exit code, such as invalid arguments.
+	exitGeneralError = 1
+	// exitConfigNotFound is used when the config file of a package manager or the manifest does not exist.
+	exitConfigNotFound = 66
+	// exitCommandUnavailable is used when a command is not available for the package manager.
+	exitCommandUnavailable = 69
+	// exitPermissionDenied is used when a command cannot be run with the privileges of ipm.
+	exitPermissionDenied = 77
+	// exitInvalidConfig is used when a config file cannot be read or fails schema validation.
+	exitInvalidConfig = 78
+	// exitCommandNotStarted is used when the package manager command cannot be started.
+	exitCommandNotStarted = 127
+)
+
+// exitWithError reports the error on stderr and exits with the matching exit code.
+//
+// Parameters:
+//   - err: The error returned by a command or by the setup of the commands.
+//
+// Example usage:
+//
+//	if err := rootCmd.Execute(); err != nil {
+//		exitWithError(err)
+//	}
+//
+// This function performs the following steps:
+//  1. Prints the error message to stderr.
+//  2. Exits the program with the exit code returned by exitCode.
+func exitWithError(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(exitCode(err))
+}
+
+// exitCode returns the exit code used to report the error.
+//
+// Parameters:
+//   - err: The error to report.
+//
+// Returns:
+//   - int: The exit status of the package manager for failed commands, or one of the
+//     stable exit codes of ipm for its own errors.
+//
+// Example usage:
+//
+//	os.Exit(exitCode(err))
+//
+// This function performs the following steps:
+//  1. Returns the exit status of the package manager if a command failed after it started.
+//  2. Returns exitCommandNotStarted if the command could not be started.
+//  3. Returns exitCommandUnavailable if the command is not available.
+//  4. Returns exitPermissionDenied if the command cannot be run with the privileges of ipm.
+//  5. Returns exitConfigNotFound or exitInvalidConfig for configuration errors.
+//  6. Returns exitGeneralError for any other error.
+func exitCode(err error) int {
+	var commandErr *utils.CommandFailedError
+	if errors.As(err, &commandErr) {
+		if commandErr.ExitCode > 0 {
+			return commandErr.ExitCode
+		}
+		return exitCommandNotStarted
+	}
+
+	var unavailableErr *utils.CommandUnavailableError
+	if errors.As(err, &unavailableErr) {
+		return exitCommandUnavailable
+	}
+
+	var permissionErr *utils.PermissionError
+	if errors.As(err, &permissionErr) {
+		return exitPermissionDenied
+	}
+
+	var notFoundErr *utils.ConfigNotFoundError
+	if errors.As(err, &notFoundErr) {
+		return exitConfigNotFound
+	}
+
+	var manifestNotFoundErr *utils.ManifestNotFoundError
+	if errors.As(err, &manifestNotFoundErr) {
+		return exitConfigNotFound
+	}
+
+	var invalidErr *utils.InvalidConfigError
+	if errors.As(err, &invalidErr) {
+		return exitInvalidConfig
+	}
+
+	return exitGeneralError
+}
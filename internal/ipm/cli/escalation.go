@@ -0,0 +1,76 @@
+// Package cli provides command-line interface utilities for the IPM application.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"ipm/internal/ipm/config"
+	"ipm/internal/ipm/manager"
+
+	"github.com/spf13/cobra"
+)
+
+// AddEscalationCommand adds the escalation command to the manager command.
+//
+// Parameters:
+//   - managerCmd: The manager command to which the escalation command will be added.
+//   - paths: The locations of the configuration files for package managers and of the settings file.
+//
+// This function performs the following steps:
+//  1. Creates a new "escalation" command.
+//  2. Sets the command to show, set or clear the program running the commands that require root.
+//  3. Adds the "escalation" command to the manager command.
+//
+// Example usage:
+//
+//	managerCmd := &cobra.Command{Use: "manager"}
+//	AddEscalationCommand(managerCmd, paths)
+//
+// This function is useful for running the commands that require root with doas or pkexec
+// instead of sudo, or for disabling the escalation when ipm already has the privileges it needs.
+func AddEscalationCommand(managerCmd *cobra.Command, paths config.Paths) {
+	// Command to show, set or clear the escalation program
+	var escalationCmd = &cobra.Command{
+		Use:   "escalation [sudo|doas|pkexec|none]",
+		Short: "Show or set the program running the commands that require root",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			unset, _ := cmd.Flags().GetBool("unset")
+
+			// Clear the escalation program if the unset flag is passed
+			if unset {
+				if len(args) > 0 {
+					return errors.New("--unset does not accept a program")
+				}
+				return config.SetEscalation("", paths)
+			}
+
+			// Set the escalation program if one is passed
+			if len(args) == 1 {
+				return config.SetEscalation(args[0], paths)
+			}
+
+			// Otherwise, show the escalation program and how it was selected
+			settings, err := config.LoadSettings(paths)
+			if err != nil {
+				return err
+			}
+			if settings.Escalation != "" {
+				fmt.Printf("%s (%s)\n", settings.Escalation, selectedBySettings)
+				return nil
+			}
+			if program := manager.DetectEscalationProgram(); program != "" {
+				fmt.Printf("%s (%s)\n", program, selectedByDetection)
+				return nil
+			}
+			fmt.Println("No escalation program detected")
+			return nil
+		},
+	}
+
+	// Add flags to the escalation command
+	escalationCmd.Flags().Bool("unset", false, "Clear the escalation program and detect sudo or doas")
+
+	// Add the escalation command to the manager command
+	managerCmd.AddCommand(escalationCmd)
+}
@@ -0,0 +1,194 @@
+// Package cli provides command-line interface utilities for the IPM application.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"ipm/internal/ipm/config"
+	"ipm/internal/ipm/manager"
+	"ipm/internal/ipm/utils"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// stdoutFile is the file name that writes to the standard output instead of a file.
+const stdoutFile = "-"
+
+// AddExportCommand adds the export command to the root command.
+//
+// Parameters:
+//   - rootCmd: The root command to which the export command will be added.
+//   - paths: The locations of the configuration files for package managers.
+//
+// This function performs the following steps:
+//  1. Creates a new "export" command.
+//  2. Sets the command to write the installed packages to a manifest.
+//  3. Adds the file, force and os flags.
+//  4. Adds the "export" command to the root command.
+//
+// Example usage:
+//
+//	rootCmd := &cobra.Command{Use: "ipm"}
+//	AddExportCommand(rootCmd, paths)
+//
+// This function is useful to back up the packages of a machine, or to migrate them to
+// another machine with "ipm sync".
+func AddExportCommand(rootCmd *cobra.Command, paths config.Paths) {
+	// Command to export the installed packages to a manifest
+	var exportCmd = &cobra.Command{
+		Use:   "export [managers]",
+		Short: "Write the packages installed with every enabled package manager to a manifest",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			manifestFile, _ := cmd.Flags().GetString("file")
+			force, _ := cmd.Flags().GetBool("force")
+			perOS, _ := cmd.Flags().GetBool("os")
+			if dryRun {
+				manifestFile = stdoutFile
+			}
+			return runExport(paths, args, manifestFile, force, perOS)
+		},
+	}
+
+	// Add flags to the export command
+	exportCmd.Flags().StringP("file", "f", config.ManifestFile, "Path to the manifest, or - for the standard output")
+	exportCmd.Flags().Bool("force", false, "Overwrite the manifest if it exists")
+	exportCmd.Flags().Bool("os", false, "Declare the packages for the current operating system only")
+
+	// Add the export command to the root command
+	rootCmd.AddCommand(exportCmd)
+}
+
+// runExport writes the packages installed with the enabled package managers to a manifest.
+//
+// Parameters:
+//   - paths: The locations of the configuration files for package managers.
+//   - managerNames: The package managers to export, or none to export every enabled and
+//     installed package manager.
+//   - manifestFile: The path to the manifest, or stdoutFile to print it.
+//   - force: Whether to overwrite the manifest if it exists.
+//   - perOS: Whether to declare the packages under the current operating system.
+//
+// Returns:
+//   - error: An error if the manifest exists and force is not set, the configuration files
+//     or the settings cannot be loaded, no package manager can list its packages, or the manifest cannot be written.
+//
+// This function performs the following steps:
+//  1. Refuses to overwrite an existing manifest unless force is set.
+//  2. Validates and loads the configuration files.
+//  3. Lists the installed packages of each package manager concurrently, warning about the
+//     package managers without parse rules for the list command or whose list failed.
+//  4. Writes the package names, sorted and without duplicates, to the manifest.
+func runExport(paths config.Paths, managerNames []string, manifestFile string, force bool, perOS bool) error {
+	// Refuse to overwrite an existing manifest
+	if manifestFile != stdoutFile && !force {
+		if _, err := os.Stat(manifestFile); err == nil {
+			return fmt.Errorf("manifest %s already exists, pass --force to overwrite it", manifestFile)
+		}
+	}
+
+	// Validate and load the configuration files
+	if err := config.ValidateConfigFiles(paths); err != nil {
+		return err
+	}
+	configs, err := config.LoadConfigs(paths)
+	if err != nil {
+		return err
+	}
+	if len(managerNames) == 0 {
+		managerNames = installedManagers(configs)
+	} else {
+		for _, managerName := range managerNames {
+			if _, ok := configs[managerName]; !ok {
+				return fmt.Errorf("package manager %s has no config", managerName)
+			}
+		}
+	}
+	if len(managerNames) == 0 {
+		return errors.New("no enabled package manager is installed")
+	}
+	settings, err := config.LoadSettings(paths)
+	if err != nil {
+		return err
+	}
+
+	// List the installed packages of each package manager concurrently
+	packages := make([][]string, len(managerNames))
+	warnings := make([]error, len(managerNames))
+	escalated := func(managerName string) bool {
+		return requiresEscalation(configs[managerName], listCommand, settings.Escalation)
+	}
+	forEachManager(managerNames, configs, defaultJobs, escalated, func(i int, managerName string) {
+		records, err := installedPackages(managerName, configs[managerName], settings.Escalation)
+		if err != nil {
+			warnings[i] = err
+			return
+		}
+		packages[i] = packageNames(records)
+	})
+
+	// Collect the packages of the package managers that listed them
+	managers := make(map[string][]string)
+	for i, managerName := range managerNames {
+		if warnings[i] != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v, skipping\n", managerName, warnings[i])
+			continue
+		}
+		if len(packages[i]) > 0 {
+			managers[managerName] = packages[i]
+		}
+	}
+	if len(managers) == 0 {
+		return errors.New("no package manager listed any package")
+	}
+
+	// Declare the packages for every operating system or for the current one
+	manifest := utils.Manifest{Managers: managers}
+	if perOS {
+		manifest = utils.Manifest{OS: map[string]utils.ManifestPackages{runtime.GOOS: {Managers: managers}}}
+	}
+
+	// Write the manifest
+	if manifestFile == stdoutFile {
+		data, err := config.MarshalManifest(manifest)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := config.WriteManifest(manifestFile, manifest); err != nil {
+		return err
+	}
+	exported := make([]string, 0, len(managers))
+	for managerName := range managers {
+		exported = append(exported, managerName)
+	}
+	sort.Strings(exported)
+	fmt.Printf("Exported the packages of %s to %s\n", strings.Join(exported, ", "), manifestFile)
+	return nil
+}
+
+// packageNames returns the names of the packages of parsed records.
+//
+// Parameters:
+//   - records: The parsed package records.
+//
+// Returns:
+//   - []string: The package names, sorted and without duplicates.
+func packageNames(records []manager.Record) []string {
+	seen := make(map[string]bool, len(records))
+	names := make([]string, 0, len(records))
+	for _, record := range records {
+		if !seen[record.Package] {
+			seen[record.Package] = true
+			names = append(names, record.Package)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,93 @@
+// Package cli provides command-line interface utilities for the IPM application.
+package cli
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// configDirEnv is the environment variable overriding the config directory.
+const configDirEnv = "IPM_CONFIG_DIR"
+
+// managerEnv is the environment variable selecting the package manager of the default commands.
+const managerEnv = "IPM_MANAGER"
+
+// globalFlags holds the values of the global flags that are needed before the commands are created.
+//
+// Fields:
+//   - configDir: The value of the --config-dir flag, or of the IPM_CONFIG_DIR environment variable.
+//   - schemaFile: The value of the --schema flag.
+//   - manager: The value of the --manager flag.
+//   - managerEnv: The value of the IPM_MANAGER environment variable.
+type globalFlags struct {
+	configDir  string
+	schemaFile string
+	manager    string
+	managerEnv string
+}
+
+// addGlobalFlags adds the global flags shared by all commands to the root command.
+//
+// Parameters:
+//   - rootCmd: The root command to which the global flags will be added.
+//
+// Example usage:
+//
+//	rootCmd := &cobra.Command{Use: "ipm"}
+//	addGlobalFlags(rootCmd)
+func addGlobalFlags(rootCmd *cobra.Command) {
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Print the commands without executing them")
+	rootCmd.PersistentFlags().String("config-dir", "", "Directory of the package manager configs, replacing ipm/manager in the user and system config directories (env "+configDirEnv+")")
+	rootCmd.PersistentFlags().String("schema", "", "Path to the JSON schema of the package manager configs")
+	rootCmd.PersistentFlags().StringP("manager", "m", "", "Package manager used by the default commands (env "+managerEnv+")")
+}
+
+// parseGlobalFlags extracts the global flags that are needed before the commands are created.
+//
+// The commands of the package managers are created from their configs before cobra parses
+// the command line, so the global flags of the root command are parsed ahead of time. The
+// flags of the subcommands are not known yet and are ignored, and errors are left to cobra
+// when it parses the command line again to run the command.
+//
+// Parameters:
+//   - rootCmd: The root command holding the global flags.
+//   - args: The command-line arguments, including the program name.
+//
+// Returns:
+//   - globalFlags: The values of the global flags, falling back to the environment variables.
+//   - []string: The program name followed by the positional arguments.
+//
+// Example usage:
+//
+//	flags, positionalArgs := parseGlobalFlags(rootCmd, os.Args)
+//
+// This function performs the following steps:
+//  1. Parses the global flags with cobra, ignoring the unknown flags, so that the
+//     "--manager apt", "--manager=apt", "-m apt" and "-mapt" forms are all supported and
+//     flag parsing stops at "--".
+//  2. Reads the values of the --config-dir, --schema and --manager (-m) flags.
+//  3. Falls back to the IPM_CONFIG_DIR environment variable if --config-dir is not set.
+//  4. Reads the IPM_MANAGER environment variable.
+func parseGlobalFlags(rootCmd *cobra.Command, args []string) (globalFlags, []string) {
+	// Parse the global flags, ignoring the flags of the subcommands
+	rootCmd.InitDefaultHelpFlag()
+	rootCmd.FParseErrWhitelist.UnknownFlags = true
+	_ = rootCmd.ParseFlags(args[1:])
+	rootCmd.FParseErrWhitelist.UnknownFlags = false
+
+	// Read the values of the global flags
+	var flags globalFlags
+	flags.configDir, _ = rootCmd.PersistentFlags().GetString("config-dir")
+	flags.schemaFile, _ = rootCmd.PersistentFlags().GetString("schema")
+	flags.manager, _ = rootCmd.PersistentFlags().GetString("manager")
+	positionalArgs := append(args[:1:1], rootCmd.Flags().Args()...)
+
+	// Fall back to the environment variables
+	if flags.configDir == "" {
+		flags.configDir = os.Getenv(configDirEnv)
+	}
+	flags.managerEnv = os.Getenv(managerEnv)
+
+	return flags, positionalArgs
+}
@@ -11,7 +11,7 @@ import (
 //
 // Parameters:
 //   - managerCmd: The manager command to which the generate command will be added.
-//   - configDir: The directory containing the configuration files for package managers.
+//   - paths: The locations of the configuration files for package managers.
 //
 // This function performs the following steps:
 //  1. Creates a new "generate" command.
@@ -21,19 +21,19 @@ import (
 // Example usage:
 //
 //	managerCmd := &cobra.Command{Use: "manager"}
-//	AddGenerateCommand(managerCmd, "/path/to/configDir")
+//	AddGenerateCommand(managerCmd, paths)
 //
 // This function is useful for generating a new configuration file for a specified
 // package manager. It creates a new configuration file in the specified directory
 // and prints the result.
-func AddGenerateCommand(managerCmd *cobra.Command, configDir string) {
+func AddGenerateCommand(managerCmd *cobra.Command, paths config.Paths) {
 	// Command to generate a new package manager config
 	var generateCmd = &cobra.Command{
 		Use:   "generate [manager]",
 		Short: "Generate a new package manager config",
 		Args:  cobra.ExactArgs(1),
-		Run: func(cmd *cobra.Command, args []string) {
-			config.GenerateManagerConfig(args[0], configDir)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return config.GenerateManagerConfig(args[0], paths)
 		},
 	}
 
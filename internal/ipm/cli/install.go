@@ -0,0 +1,108 @@
+// Package cli provides command-line interface utilities for the IPM application.
+package cli
+
+import (
+	"fmt"
+	"ipm/internal/ipm/manager"
+	"ipm/internal/ipm/utils"
+)
+
+// installCommand is the name of the command installing packages.
+const installCommand = "install"
+
+// executeCommand runs a command of a package manager config.
+//
+// Packages passed to the install command with the "name@version" syntax are installed
+// with the install-version command instead, one at a time, with their name rendered as
+// .Package and their version as .Version.
+//
+// Parameters:
+//   - managerName: The name of the package manager.
+//   - managerConfig: The config of the package manager.
+//   - command: The name of the command in the config, e.g. "install".
+//   - params: The parameters passed to the command template.
+//   - options: The options controlling batching, dry-run, fallback behavior and output streams.
+//
+// Returns:
+//   - error: A utils.CommandUnavailableError if the command is not available, or if packages
+//     are pinned to a version and the config has no install-version command, or an error if
+//     a command cannot be rendered or fails.
+//
+// Example usage:
+//
+//	err := executeCommand("npm", npmConfig, "install", []string{"typescript@5.4.5", "eslint"}, manager.ExecuteOptions{})
+//
+// This function performs the following steps:
+//  1. Runs the commands other than install as is.
+//  2. Splits the packages of the install command into pinned and unpinned packages.
+//  3. Fails before installing anything if packages are pinned and cannot be.
+//  4. Installs the unpinned packages with the install command.
+//  5. Installs each pinned package with the install-version command.
+func executeCommand(managerName string, managerConfig utils.CommandConfig, command string, params []string, options manager.ExecuteOptions) error {
+	if command != installCommand {
+		return manager.ExecuteCommandTemplate(command, managerConfig.Commands[command], params, options)
+	}
+
+	// Split the packages into pinned and unpinned packages
+	names, versions := splitPinnedPackages(params)
+	var unpinned, pinned []string
+	for _, name := range names {
+		if versions[name] != "" {
+			pinned = append(pinned, name)
+		} else {
+			unpinned = append(unpinned, name)
+		}
+	}
+
+	// Fail before installing anything if the package manager cannot pin versions
+	installVersion := managerConfig.Commands[installVersionCommand]
+	if len(pinned) > 0 && !installVersion.IsAvailable() {
+		return fmt.Errorf("%s cannot install %s pinned to a version: %w", managerName, pinned[0], &utils.CommandUnavailableError{Command: installVersionCommand})
+	}
+
+	// Install the unpinned packages
+	if len(unpinned) > 0 || len(pinned) == 0 {
+		if err := manager.ExecuteCommandTemplate(command, managerConfig.Commands[command], unpinned, options); err != nil {
+			return err
+		}
+	}
+
+	// Install each pinned package with the install-version command
+	for _, packageName := range pinned {
+		pinnedOptions := options
+		pinnedOptions.Version = versions[packageName]
+		if err := manager.ExecuteCommandTemplate(installVersionCommand, installVersion, []string{packageName}, pinnedOptions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitPinnedPackages splits packages passed with the "name@version" syntax into their names and versions.
+//
+// The install command, and thus the all command, and the sync command use it so that a
+// package is pinned the same way on the command line and in the manifest.
+//
+// Parameters:
+//   - params: The packages, each one optionally pinned to a version, e.g. "jq@1.6".
+//
+// Returns:
+//   - []string: The names of the packages, in the same order.
+//   - map[string]string: A map where the keys are the names of the pinned packages and the
+//     values are their versions.
+//
+// Example usage:
+//
+//	names, versions := splitPinnedPackages([]string{"jq@1.6", "fd"}) // ["jq", "fd"], {"jq": "1.6"}
+func splitPinnedPackages(params []string) ([]string, map[string]string) {
+	names := make([]string, 0, len(params))
+	versions := make(map[string]string)
+	for _, param := range params {
+		name, version := utils.SplitVersion(param)
+		names = append(names, name)
+		if version != "" {
+			versions[name] = version
+		}
+	}
+	return names, versions
+}
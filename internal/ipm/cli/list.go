@@ -11,7 +11,7 @@ import (
 //
 // Parameters:
 //   - managerCmd: The manager command to which the list command will be added.
-//   - configDir: The directory containing the configuration files for package managers.
+//   - paths: The locations of the configuration files for package managers.
 //
 // This function performs the following steps:
 //  1. Creates a new "list" command.
@@ -21,27 +21,26 @@ import (
 // Example usage:
 //
 //	managerCmd := &cobra.Command{Use: "manager"}
-//	AddListCommand(managerCmd, "/path/to/configDir")
+//	AddListCommand(managerCmd, paths)
 //
 // This function is useful for listing package managers. It provides options to list
 // all package managers, only enabled package managers, or only disabled package managers.
-func AddListCommand(managerCmd *cobra.Command, configDir string) {
+func AddListCommand(managerCmd *cobra.Command, paths config.Paths) {
 	// Command to list package managers
 	var listCmd = &cobra.Command{
 		Use:   "list",
 		Short: "List package managers",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			all, _ := cmd.Flags().GetBool("all")
 			enabled, _ := cmd.Flags().GetBool("enabled")
 			disabled, _ := cmd.Flags().GetBool("disabled")
 
 			// If no flag is passed, return help output
 			if !all && !enabled && !disabled {
-				cmd.Help()
-				return
+				return cmd.Help()
 			}
 
-			config.ListManagers(configDir, all, enabled, disabled)
+			return config.ListManagers(paths, all, enabled, disabled)
 		},
 	}
 
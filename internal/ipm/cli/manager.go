@@ -2,6 +2,8 @@
 package cli
 
 import (
+	"ipm/internal/ipm/config"
+
 	"github.com/spf13/cobra"
 )
 
@@ -9,8 +11,8 @@ import (
 //
 // Parameters:
 //   - rootCmd: The root command to which the manager commands will be added.
-//   - configDir: The directory containing the configuration files for package managers.
-//   - schemaFile: The path to the JSON schema file used for validation.
+//   - paths: The locations of the configuration files for package managers and of the JSON schema file.
+//   - flags: The global flags, used to show the package manager of the default commands.
 //
 // This function performs the following steps:
 //  1. Creates the manager command.
@@ -20,8 +22,10 @@ import (
 //  5. Adds the list command to the manager command.
 //  6. Adds the generate command to the manager command.
 //  7. Adds the delete command to the manager command.
-//  8. Adds the manager command to the root command.
-func SetupManagerCommands(rootCmd *cobra.Command, configDir string, schemaFile string) {
+//  8. Adds the default command to the manager command.
+//  9. Adds the escalation command to the manager command.
+//  10. Adds the manager command to the root command.
+func SetupManagerCommands(rootCmd *cobra.Command, paths config.Paths, flags globalFlags) {
 	// Create the manager command
 	var managerCmd = &cobra.Command{
 		Use:   "manager",
@@ -29,22 +33,28 @@ func SetupManagerCommands(rootCmd *cobra.Command, configDir string, schemaFile s
 	}
 
 	// Add the validate command to the manager command
-	AddValidateCommand(managerCmd, configDir, schemaFile)
+	AddValidateCommand(managerCmd, paths)
 
 	// Add the enable command to the manager command
-	AddEnableCommand(managerCmd, configDir)
+	AddEnableCommand(managerCmd, paths)
 
 	// Add the disable command to the manager command
-	AddDisableCommand(managerCmd, configDir)
+	AddDisableCommand(managerCmd, paths)
 
 	// Add the list command to the manager command
-	AddListCommand(managerCmd, configDir)
+	AddListCommand(managerCmd, paths)
 
 	// Add the generate command to the manager command
-	AddGenerateCommand(managerCmd, configDir)
+	AddGenerateCommand(managerCmd, paths)
 
 	// Add the delete command to the manager command
-	AddDeleteCommand(managerCmd, configDir)
+	AddDeleteCommand(managerCmd, paths)
+
+	// Add the default command to the manager command
+	AddDefaultCommand(managerCmd, paths, flags)
+
+	// Add the escalation command to the manager command
+	AddEscalationCommand(managerCmd, paths)
 
 	// Add the manager command to the root command
 	rootCmd.AddCommand(managerCmd)
@@ -0,0 +1,280 @@
+// Package cli provides command-line interface utilities for the IPM application.
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"ipm/internal/ipm/config"
+	"ipm/internal/ipm/manager"
+	"ipm/internal/ipm/utils"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Output formats of the records parsed from the output of the commands.
+const (
+	// outputTable prints the records as a table.
+	outputTable = "table"
+	// outputJSON prints the records as a JSON array.
+	outputJSON = "json"
+	// outputYAML prints the records as a YAML sequence.
+	outputYAML = "yaml"
+)
+
+// addOutputFlag adds the output flag to a command if its output can be parsed.
+//
+// The search command always accepts the output flag, which also formats the results of
+// "search --everywhere", parsed with the rules of the other package managers.
+//
+// Parameters:
+//   - cmd: The command to which the output flag will be added.
+//   - command: The name of the command in the config, e.g. "list".
+//   - managerConfig: The config of the package manager.
+func addOutputFlag(cmd *cobra.Command, command string, managerConfig utils.CommandConfig) {
+	if _, ok := managerConfig.Parse[command]; ok || command == searchCommand {
+		cmd.Flags().StringP("output", "o", "", "Print the parsed packages as "+outputTable+", "+outputJSON+" or "+outputYAML)
+	}
+}
+
+// runConfigCommand runs a command of a package manager config from a cobra command.
+//
+// Parameters:
+//   - cmd: The cobra command being run, used to read the dry-run and output flags.
+//   - paths: The locations of the configuration files, including the package name mapping.
+//   - managerName: The name of the package manager.
+//   - managerConfig: The config of the package manager.
+//   - command: The name of the command in the config, e.g. "install".
+//   - args: The parameters passed to the command template, as logical package names.
+//
+// Returns:
+//   - error: An error if the package name mapping or the settings cannot be loaded, or the
+//     command is not available, cannot be rendered, run or parsed.
+//
+// This function performs the following steps:
+//  1. Reads the dry-run and output flags, and searches with every package manager if the
+//     everywhere flag of the search command is passed.
+//  2. Resolves the logical package names to the package ids of the package manager, except
+//     for the search command whose parameters are search terms.
+//  3. Runs the command and prints its parsed output if the output flag is set.
+//  4. Otherwise, runs the command with its output streamed, installing the packages pinned
+//     with the "name@version" syntax with the install-version command.
+//
+// Commands that require root are run with the escalation program of the settings.
+func runConfigCommand(cmd *cobra.Command, paths config.Paths, managerName string, managerConfig utils.CommandConfig, command string, args []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	format, _ := cmd.Flags().GetString("output")
+
+	// Search with every package manager if the everywhere flag is passed
+	if everywhere, _ := cmd.Flags().GetBool("everywhere"); everywhere {
+		return runSearchEverywhere(paths, args, dryRun, format)
+	}
+
+	// Resolve the logical package names to the package ids of the package manager
+	if command != searchCommand {
+		mapping, err := config.LoadMapping(paths)
+		if err != nil {
+			return err
+		}
+		args = mapping.ResolveAll(managerName, args)
+	}
+
+	// Read the program escalating the commands that require root
+	settings, err := config.LoadSettings(paths)
+	if err != nil {
+		return err
+	}
+
+	// Print the parsed output if the output flag is set
+	if format != "" {
+		return runParsedCommand(managerName, managerConfig, command, args, format, dryRun, settings.Escalation)
+	}
+
+	return executeCommand(managerName, managerConfig, command, args, manager.ExecuteOptions{
+		Batch:      managerConfig.Batch,
+		DryRun:     dryRun,
+		Fallback:   managerConfig.Fallbacks[command],
+		Escalation: settings.Escalation,
+		ForbidRoot: managerConfig.ForbidRoot,
+	})
+}
+
+// parsedCommand returns the parse rules of a command and the command run to parse its output.
+//
+// Parameters:
+//   - managerConfig: The config of the package manager.
+//   - command: The name of the command in the config.
+//
+// Returns:
+//   - utils.ParseSpec: The parse rules of the command.
+//   - utils.Command: The command of the parse rules if they declare one, or the command of the config.
+//   - bool: True if the command has parse rules, false otherwise.
+func parsedCommand(managerConfig utils.CommandConfig, command string) (utils.ParseSpec, utils.Command, bool) {
+	spec, ok := managerConfig.Parse[command]
+	if !ok {
+		return utils.ParseSpec{}, utils.Command{}, false
+	}
+	if spec.Run != nil {
+		return spec, *spec.Run, true
+	}
+	return spec, managerConfig.Commands[command], true
+}
+
+// runParsedCommand runs a command and prints its parsed output in the given format.
+//
+// Parameters:
+//   - managerName: The name of the package manager.
+//   - managerConfig: The config of the package manager.
+//   - command: The name of the command in the config.
+//   - args: The parameters passed to the command template.
+//   - format: The output format, outputTable, outputJSON or outputYAML.
+//   - dryRun: Whether to print the final command without running it.
+//   - escalation: The program running the command if it requires root, from the settings.
+//
+// Returns:
+//   - error: An error if the format is unknown, the command has no parse rules, or the
+//     command cannot be run or its output cannot be parsed.
+//
+// This function performs the following steps:
+//  1. Checks the output format and finds the parse rules of the command.
+//  2. Runs the command of the parse rules, or the command of the config, capturing its output.
+//  3. Parses the output into package records.
+//  4. Prints the records in the given format.
+func runParsedCommand(managerName string, managerConfig utils.CommandConfig, command string, args []string, format string, dryRun bool, escalation string) error {
+	// Check the output format and find the parse rules
+	if err := checkOutputFormat(format); err != nil {
+		return err
+	}
+	spec, commandTemplate, ok := parsedCommand(managerConfig, command)
+	if !ok {
+		return fmt.Errorf("command %s has no parse rule for %s, pass --everywhere to parse the results of the other package managers", command, managerName)
+	}
+
+	// Run the command, printing it without running it in dry-run mode
+	var stdout bytes.Buffer
+	options := manager.ExecuteOptions{
+		Batch:      managerConfig.Batch,
+		DryRun:     dryRun,
+		Fallback:   managerConfig.Fallbacks[command],
+		Quiet:      true,
+		Stdout:     &stdout,
+		Escalation: escalation,
+		ForbidRoot: managerConfig.ForbidRoot,
+	}
+	if dryRun {
+		options.Stdout = nil
+	}
+	if err := manager.ExecuteCommandTemplate(command, commandTemplate, args, options); err != nil || dryRun {
+		return err
+	}
+
+	// Parse the output and print the records
+	records, err := manager.ParseOutput(managerName, spec, stdout.Bytes())
+	if err != nil {
+		return err
+	}
+	return writeRecords(os.Stdout, records, format)
+}
+
+// checkOutputFormat checks that the output format is supported.
+//
+// Parameters:
+//   - format: The output format passed with the output flag.
+//
+// Returns:
+//   - error: An error if the format is not outputTable, outputJSON or outputYAML.
+func checkOutputFormat(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputYAML:
+		return nil
+	}
+	return fmt.Errorf("invalid output format %s, must be %s, %s or %s", format, outputTable, outputJSON, outputYAML)
+}
+
+// writeRecords writes package records in the given format.
+//
+// Parameters:
+//   - w: The writer receiving the records.
+//   - records: The package records.
+//   - format: The output format, outputTable, outputJSON or outputYAML.
+//
+// Returns:
+//   - error: An error if the records cannot be encoded or written.
+//
+// Example output with the table format:
+//
+//	MANAGER  PACKAGE  VERSION  DESCRIPTION
+//	apt      jq       1.6-2.1  lightweight and flexible command-line JSON processor
+func writeRecords(w io.Writer, records []manager.Record, format string) error {
+	switch format {
+	case outputJSON:
+		if records == nil {
+			records = []manager.Record{}
+		}
+		data, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case outputYAML:
+		_, err := io.WriteString(w, recordsYAML(records))
+		return err
+	default:
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "MANAGER\tPACKAGE\tVERSION\tDESCRIPTION")
+		for _, record := range records {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", record.Manager, record.Package, record.Version, record.Description)
+		}
+		return writer.Flush()
+	}
+}
+
+// recordsYAML encodes package records as a YAML sequence of mappings.
+//
+// Every value is written as a double-quoted scalar, encoded as a JSON string, which is
+// also valid YAML, so that values such as "1.10" or "yes" keep their string type.
+//
+// Parameters:
+//   - records: The package records.
+//
+// Returns:
+//   - string: The YAML document.
+//
+// Example output:
+//
+//	# one mapping per package
+//	- manager: "apt"
+//	  package: "jq"
+//	  version: "1.6-2.1"
+//	  description: "lightweight and flexible command-line JSON processor"
+func recordsYAML(records []manager.Record) string {
+	if len(records) == 0 {
+		return "[]\n"
+	}
+
+	var builder strings.Builder
+	for _, record := range records {
+		fmt.Fprintf(&builder, "- manager: %s\n", yamlString(record.Manager))
+		fmt.Fprintf(&builder, "  package: %s\n", yamlString(record.Package))
+		fmt.Fprintf(&builder, "  version: %s\n", yamlString(record.Version))
+		fmt.Fprintf(&builder, "  description: %s\n", yamlString(record.Description))
+	}
+	return builder.String()
+}
+
+// yamlString encodes a string as a double-quoted YAML scalar.
+//
+// Parameters:
+//   - value: The string to encode.
+//
+// Returns:
+//   - string: The JSON encoding of the string, which is a valid double-quoted YAML scalar.
+func yamlString(value string) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -0,0 +1,164 @@
+// Package cli provides command-line interface utilities for the IPM application.
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"ipm/internal/ipm/config"
+	"ipm/internal/ipm/manager"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// searchCommand is the name of the command searched across all package managers.
+const searchCommand = "search"
+
+// addEverywhereFlag adds the everywhere flag to the search command.
+//
+// Parameters:
+//   - cmd: The command to which the everywhere flag will be added.
+//   - command: The name of the command in the config, e.g. "search".
+func addEverywhereFlag(cmd *cobra.Command, command string) {
+	if command == searchCommand {
+		cmd.Flags().Bool("everywhere", false, "Search with every enabled and installed package manager and merge the results")
+	}
+}
+
+// runSearchEverywhere runs the search command of every enabled and installed package manager
+// and prints the merged results.
+//
+// Parameters:
+//   - paths: The locations of the configuration files for package managers.
+//   - params: The search terms passed to the search command templates.
+//   - dryRun: Whether to print the final commands without running them.
+//   - format: The output format of the merged results, outputTable by default.
+//
+// Returns:
+//   - error: An error if no search term is given, the output format is unknown, the configuration files or the settings
+//     cannot be loaded, no package manager can search, or the search failed for every package manager.
+//
+// Example usage:
+//
+//	err := runSearchEverywhere(paths, []string{"jq"}, false, outputTable)
+//
+// This function performs the following steps:
+//  1. Validates and loads the configuration files of all package managers.
+//  2. Keeps the enabled and installed package managers whose search command is available.
+//  3. Runs their search commands concurrently, capturing their output.
+//  4. Parses the output of each search command with the parse rules of its config, warning
+//     about the package managers without parse rules or whose search failed.
+//  5. Prints the merged results as records of manager, package, version and description.
+func runSearchEverywhere(paths config.Paths, params []string, dryRun bool, format string) error {
+	if len(params) == 0 {
+		return errors.New("search --everywhere requires a search term")
+	}
+	if format == "" {
+		format = outputTable
+	}
+	if err := checkOutputFormat(format); err != nil {
+		return err
+	}
+
+	// Validate and load the configuration files
+	if err := config.ValidateConfigFiles(paths); err != nil {
+		return err
+	}
+	configs, err := config.LoadConfigs(paths)
+	if err != nil {
+		return err
+	}
+
+	// Keep the package managers that can search
+	var managerNames []string
+	for _, managerName := range installedManagers(configs) {
+		if configs[managerName].Commands[searchCommand].IsAvailable() {
+			managerNames = append(managerNames, managerName)
+		}
+	}
+	if len(managerNames) == 0 {
+		return errors.New("no enabled and installed package manager can search")
+	}
+	settings, err := config.LoadSettings(paths)
+	if err != nil {
+		return err
+	}
+
+	// Print the search commands without running them in dry-run mode
+	if dryRun {
+		for _, managerName := range managerNames {
+			fmt.Printf("==> %s\n", managerName)
+			commandTemplate := configs[managerName].Commands[searchCommand]
+			if _, parseCommand, ok := parsedCommand(configs[managerName], searchCommand); ok {
+				commandTemplate = parseCommand
+			}
+			if err := manager.ExecuteCommandTemplate(searchCommand, commandTemplate, params, manager.ExecuteOptions{
+				Batch:      configs[managerName].Batch,
+				DryRun:     true,
+				Escalation: settings.Escalation,
+				ForbidRoot: configs[managerName].ForbidRoot,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Run the search commands concurrently and parse their output
+	records := make([][]manager.Record, len(managerNames))
+	warnings := make([]string, len(managerNames))
+	escalated := func(managerName string) bool {
+		return requiresEscalation(configs[managerName], searchCommand, settings.Escalation)
+	}
+	forEachManager(managerNames, configs, defaultJobs, escalated, func(i int, managerName string) {
+		managerConfig := configs[managerName]
+		spec, commandTemplate, ok := parsedCommand(managerConfig, searchCommand)
+		if !ok {
+			warnings[i] = fmt.Sprintf("no parse rule for %s, skipping", searchCommand)
+			return
+		}
+
+		// Run the search command with its output captured
+		var stdout, stderr bytes.Buffer
+		if err := manager.ExecuteCommandTemplate(searchCommand, commandTemplate, params, manager.ExecuteOptions{
+			Batch:      managerConfig.Batch,
+			Quiet:      true,
+			Stdout:     &stdout,
+			Stderr:     &stderr,
+			Escalation: settings.Escalation,
+			ForbidRoot: managerConfig.ForbidRoot,
+		}); err != nil {
+			warnings[i] = fmt.Sprintf("%v %s", err, strings.TrimSpace(stderr.String()))
+			return
+		}
+
+		// Parse the output of the search command
+		parsed, err := manager.ParseOutput(managerName, spec, stdout.Bytes())
+		if err != nil {
+			warnings[i] = err.Error()
+			return
+		}
+		records[i] = parsed
+	})
+
+	// Print the warnings and count the package managers whose search failed
+	failed := 0
+	for i, warning := range warnings {
+		if warning != "" {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", managerNames[i], strings.TrimSpace(warning))
+			failed++
+		}
+	}
+	if failed == len(managerNames) {
+		return fmt.Errorf("%s failed for every package manager", searchCommand)
+	}
+
+	// Print the merged results
+	var merged []manager.Record
+	for _, managerRecords := range records {
+		merged = append(merged, managerRecords...)
+	}
+	return writeRecords(os.Stdout, merged, format)
+}
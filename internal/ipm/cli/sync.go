@@ -0,0 +1,526 @@
+// Package cli provides command-line interface utilities for the IPM application.
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"ipm/internal/ipm/config"
+	"ipm/internal/ipm/manager"
+	"ipm/internal/ipm/utils"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// listCommand is the name of the command listing the installed packages.
+const listCommand = "list"
+
+// installVersionCommand is the name of the command installing a package pinned to a version.
+const installVersionCommand = "install-version"
+
+// syncResult represents the result of synchronizing the packages of a package manager.
+//
+// Fields:
+//   - managerName: The name of the package manager.
+//   - wanted: The packages declared in the manifest.
+//   - missing: The wanted packages that were not installed.
+//   - extras: The installed packages that are not declared in the manifest.
+//   - listed: Whether the installed packages were listed, so that missing and extras are known.
+//   - versions: A map where the keys are the wanted packages and the values are their
+//     installed versions, recorded in the lock file.
+//   - result: The result of the synchronization, one of the result constants.
+//   - err: The error that made the synchronization fail, or nil if it succeeded.
+type syncResult struct {
+	managerName string
+	wanted      []string
+	missing     []string
+	extras      []string
+	listed      bool
+	versions    map[string]string
+	result      string
+	err         error
+}
+
+// AddSyncCommand adds the sync command to the root command.
+//
+// Parameters:
+//   - rootCmd: The root command to which the sync command will be added.
+//   - paths: The locations of the configuration files for package managers.
+//   - flags: The global flags, used to select the package manager of the packages declared without one.
+//
+// This function performs the following steps:
+//  1. Creates a new "sync" command.
+//  2. Sets the command to install the packages of the manifest that are missing.
+//  3. Adds the file flag choosing the manifest, the extras flag listing the extra packages and
+//     the update-lock flag recording the versions of every package in the lock file.
+//  4. Adds the "sync" command to the root command.
+//
+// Example usage:
+//
+//	rootCmd := &cobra.Command{Use: "ipm"}
+//	AddSyncCommand(rootCmd, paths, flags)
+//
+// This function is useful to check a manifest into a project repository and have every
+// developer machine converge on the same packages, whatever package managers it uses.
+func AddSyncCommand(rootCmd *cobra.Command, paths config.Paths, flags globalFlags) {
+	// Command to install the packages of the manifest
+	var syncCmd = &cobra.Command{
+		Use:   "sync",
+		Short: "Install the packages of the manifest that are missing",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			manifestFile, _ := cmd.Flags().GetString("file")
+			showExtras, _ := cmd.Flags().GetBool("extras")
+			updateLock, _ := cmd.Flags().GetBool("update-lock")
+			return runSync(paths, flags, manifestFile, dryRun, showExtras, updateLock)
+		},
+	}
+
+	// Add flags to the sync command
+	syncCmd.Flags().StringP("file", "f", config.ManifestFile, "Path to the manifest")
+	syncCmd.Flags().Bool("extras", false, "List the installed packages that are not in the manifest")
+	syncCmd.Flags().Bool("update-lock", false, "Record the installed version of every package in the lock file, even the pinned ones")
+
+	// Add the sync command to the root command
+	rootCmd.AddCommand(syncCmd)
+}
+
+// runSync installs the packages of a manifest that are missing, pinned to the versions of its lock file.
+//
+// Parameters:
+//   - paths: The locations of the configuration files for package managers.
+//   - flags: The global flags, used to select the package manager of the packages declared without one.
+//   - manifestFile: The path to the manifest.
+//   - dryRun: Whether to print the install commands without running them.
+//   - showExtras: Whether to list the installed packages that are not in the manifest.
+//   - updateLock: Whether to record the installed version of every package in the lock
+//     file, instead of only the packages that are not pinned yet.
+//
+// Returns:
+//   - error: An error if the manifest, its lock file, the configuration files or the settings cannot be loaded, no
+//     package manager is selected for the packages declared without one, or the
+//     synchronization failed for at least one package manager, or the lock file cannot be written.
+//
+// This function performs the following steps:
+//  1. Loads the manifest and its lock file, validates and loads the configuration files, and
+//     loads the settings.
+//  2. Selects the default package manager if the manifest declares packages without one.
+//  3. Resolves the packages wanted on the current operating system for each package manager,
+//     mapping their logical names to the package ids of the package manager.
+//  4. Synchronizes each package manager in turn, continuing after failures.
+//  5. Prints a summary table, and the extra packages if requested.
+//  6. Records the installed versions in the lock file, unless in dry-run mode.
+func runSync(paths config.Paths, flags globalFlags, manifestFile string, dryRun bool, showExtras bool, updateLock bool) error {
+	// Load the manifest, its lock file and the configuration files
+	manifest, err := config.LoadManifest(manifestFile)
+	if err != nil {
+		return err
+	}
+	lockFile := config.LockFile(manifestFile)
+	lock, err := config.LoadLock(lockFile)
+	if err != nil {
+		return err
+	}
+	if err := config.ValidateConfigFiles(paths); err != nil {
+		return err
+	}
+	configs, err := config.LoadConfigs(paths)
+	if err != nil {
+		return err
+	}
+	settings, err := config.LoadSettings(paths)
+	if err != nil {
+		return err
+	}
+
+	// Select the package manager of the packages declared without one
+	defaultManager := ""
+	if manifest.HasDefaultPackages(runtime.GOOS) {
+		selection, err := selectDefaultManager(flags, paths)
+		if err != nil {
+			return err
+		}
+		if selection.name == "" {
+			return fmt.Errorf("no default package manager for the packages of %s: %s", manifestFile, selection.reason)
+		}
+		defaultManager = selection.name
+	}
+
+	// Resolve the packages wanted on this operating system to the package ids of each package manager
+	wanted := manifest.Resolve(runtime.GOOS, defaultManager)
+	if len(wanted) == 0 {
+		fmt.Printf("No packages declared for %s in %s\n", runtime.GOOS, manifestFile)
+		return nil
+	}
+	mapping, err := config.LoadMapping(paths)
+	if err != nil {
+		return err
+	}
+	managerNames := make([]string, 0, len(wanted))
+	for managerName := range wanted {
+		wanted[managerName] = mapping.ResolveAll(managerName, wanted[managerName])
+		managerNames = append(managerNames, managerName)
+	}
+	sort.Strings(managerNames)
+
+	// Synchronize each package manager
+	results := make([]syncResult, 0, len(managerNames))
+	for _, managerName := range managerNames {
+		fmt.Printf("==> %s\n", managerName)
+		results = append(results, syncManager(managerName, configs, wanted[managerName], lock, dryRun, settings.Escalation))
+	}
+
+	// Print the summary table and record the installed versions
+	printSyncResults(results, showExtras)
+	if !dryRun {
+		if err := recordVersions(lockFile, lock, results, updateLock); err != nil {
+			return err
+		}
+	}
+
+	// Report the failures
+	var failed []string
+	for _, result := range results {
+		if result.result == resultFailed {
+			failed = append(failed, result.managerName)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("sync failed for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// syncManager installs the packages of a package manager that are missing.
+//
+// Parameters:
+//   - managerName: The name of the package manager.
+//   - configs: A map where the keys are package manager names and the values are their configs.
+//   - wanted: The packages declared in the manifest for the package manager, optionally
+//     pinned to a version with the "name@version" syntax.
+//   - lock: The versions the packages are pinned to.
+//   - dryRun: Whether to print the install commands without running them.
+//   - escalation: The program running the commands that require root, from the settings.
+//
+// Returns:
+//   - syncResult: The wanted, missing and extra packages, the installed versions and the
+//     result of the synchronization.
+//
+// This function performs the following steps:
+//  1. Splits the versions pinned in the manifest from the package names.
+//  2. Checks that the package manager has a config, is enabled and is installed.
+//  3. Lists the installed packages with the parse rules of the list command. Without parse
+//     rules, every wanted package is considered missing and installed again.
+//  4. Compares the wanted and installed packages, ignoring case.
+//  5. Installs the missing packages, pinned to their version in the manifest or else to their
+//     locked version, if any.
+//  6. Lists the installed packages again to find the installed versions, unless in dry-run mode.
+func syncManager(managerName string, configs map[string]utils.CommandConfig, wanted []string, lock utils.Lock, dryRun bool, escalation string) syncResult {
+	// Compare and record the packages by name, without the versions pinned in the manifest
+	wanted, pins := splitPinnedPackages(wanted)
+	result := syncResult{managerName: managerName, wanted: wanted, missing: wanted, result: resultFailed}
+
+	// Check that the package manager can be used
+	managerConfig, ok := configs[managerName]
+	if !ok {
+		result.err = fmt.Errorf("package manager %s has no config", managerName)
+		return result
+	}
+	if !managerConfig.Enabled {
+		result.err = fmt.Errorf("package manager %s is disabled", managerName)
+		return result
+	}
+	detect := utils.DetectConfig{}
+	if managerConfig.Detect != nil {
+		detect = *managerConfig.Detect
+	}
+	if !detect.IsDetected(managerName) {
+		result.err = fmt.Errorf("package manager %s is not installed", managerName)
+		return result
+	}
+
+	// List the installed packages and compare them with the wanted ones
+	installed, err := installedPackages(managerName, managerConfig, escalation)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %v, installing every package\n", managerName, err)
+	} else {
+		result.missing, result.extras = comparePackages(wanted, installed)
+		result.listed = true
+	}
+
+	// Install the missing packages
+	if len(result.missing) == 0 {
+		fmt.Println("All packages are installed")
+	} else if err := installPackages(managerName, managerConfig, result.missing, pins, lock, dryRun, escalation); err != nil {
+		result.err = err
+		return result
+	}
+	result.result = resultOK
+
+	// List the installed packages again to find the versions of the installed packages
+	if !dryRun && result.listed {
+		if len(result.missing) > 0 {
+			if installed, err = installedPackages(managerName, managerConfig, escalation); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %v, not recording the installed versions\n", managerName, err)
+				return result
+			}
+		}
+		result.versions = installedVersions(wanted, installed)
+	}
+	return result
+}
+
+// installPackages installs packages, pinned to their versions in the manifest or in the lock file if any.
+//
+// Parameters:
+//   - managerName: The name of the package manager.
+//   - managerConfig: The config of the package manager.
+//   - packages: The names of the packages to install.
+//   - pins: A map where the keys are package names and the values are the versions they are
+//     pinned to in the manifest, taking precedence over the lock file.
+//   - lock: The versions the packages are pinned to.
+//   - dryRun: Whether to print the install commands without running them.
+//   - escalation: The program running the commands that require root, from the settings.
+//
+// Returns:
+//   - error: An error if an install command is not available, cannot be rendered or fails.
+//
+// This function performs the following steps:
+//  1. Installs each pinned package with the install-version command, rendering its version
+//     in the manifest, or else its locked version, as .Version.
+//  2. Warns about the pinned packages when the config has no install-version command, and
+//     installs their latest version instead.
+//  3. Installs the packages that are not pinned with the install command.
+func installPackages(managerName string, managerConfig utils.CommandConfig, packages []string, pins map[string]string, lock utils.Lock, dryRun bool, escalation string) error {
+	installVersion := managerConfig.Commands[installVersionCommand]
+
+	// Install each pinned package with the install-version command
+	var unpinned []string
+	for _, packageName := range packages {
+		version := pins[packageName]
+		if version == "" {
+			version = lock.Version(managerName, packageName)
+		}
+		if version == "" {
+			unpinned = append(unpinned, packageName)
+			continue
+		}
+		if !installVersion.IsAvailable() {
+			fmt.Fprintf(os.Stderr, "Warning: %s cannot pin %s to %s, installing the latest version\n", managerName, packageName, version)
+			unpinned = append(unpinned, packageName)
+			continue
+		}
+		if err := manager.ExecuteCommandTemplate(installVersionCommand, installVersion, []string{packageName}, manager.ExecuteOptions{
+			DryRun:     dryRun,
+			Version:    version,
+			Escalation: escalation,
+			ForbidRoot: managerConfig.ForbidRoot,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Install the packages that are not pinned
+	if len(unpinned) == 0 {
+		return nil
+	}
+	return manager.ExecuteCommandTemplate(installCommand, managerConfig.Commands[installCommand], unpinned, manager.ExecuteOptions{
+		Batch:      managerConfig.Batch,
+		DryRun:     dryRun,
+		Fallback:   managerConfig.Fallbacks[installCommand],
+		Escalation: escalation,
+		ForbidRoot: managerConfig.ForbidRoot,
+	})
+}
+
+// installedVersions returns the installed versions of the wanted packages, ignoring case.
+//
+// Parameters:
+//   - wanted: The packages declared in the manifest.
+//   - installed: The installed packages.
+//
+// Returns:
+//   - map[string]string: A map where the keys are the wanted packages, as declared in the
+//     manifest, and the values are their installed versions. Packages that are not
+//     installed or whose version is unknown are left out.
+func installedVersions(wanted []string, installed []manager.Record) map[string]string {
+	versions := make(map[string]string, len(installed))
+	for _, record := range installed {
+		if record.Version != "" {
+			versions[strings.ToLower(record.Package)] = record.Version
+		}
+	}
+
+	wantedVersions := make(map[string]string, len(wanted))
+	for _, name := range wanted {
+		if version := versions[strings.ToLower(name)]; version != "" {
+			wantedVersions[name] = version
+		}
+	}
+	return wantedVersions
+}
+
+// recordVersions records the installed versions of the wanted packages in the lock file.
+//
+// Parameters:
+//   - lockFile: The path to the lock file.
+//   - lock: The versions the packages are pinned to.
+//   - results: The result of the synchronization for each package manager.
+//   - updateLock: Whether to record the installed version of every package, instead of only
+//     the packages that are not pinned yet.
+//
+// Returns:
+//   - error: An error if the lock file cannot be written.
+//
+// This function performs the following steps:
+//  1. Pins the packages that are not pinned yet to their installed version, or every package
+//     if updateLock is set.
+//  2. Warns about the pinned packages installed with another version.
+//  3. Writes the lock file if any version changed.
+func recordVersions(lockFile string, lock utils.Lock, results []syncResult, updateLock bool) error {
+	changed := false
+	for _, result := range results {
+		// Sort the packages so that the warnings are printed in a stable order
+		packageNames := make([]string, 0, len(result.versions))
+		for packageName := range result.versions {
+			packageNames = append(packageNames, packageName)
+		}
+		sort.Strings(packageNames)
+
+		for _, packageName := range packageNames {
+			version := result.versions[packageName]
+			locked := lock.Version(result.managerName, packageName)
+			if locked != "" && locked != version && !updateLock {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %s is installed with version %s but locked to %s, pass --update-lock to record it\n", result.managerName, packageName, version, locked)
+				continue
+			}
+			if lock.Set(result.managerName, packageName, version) {
+				changed = true
+			}
+		}
+	}
+
+	// Write the lock file if any version changed
+	if !changed {
+		return nil
+	}
+	if err := config.WriteLock(lockFile, lock); err != nil {
+		return err
+	}
+	fmt.Printf("Recorded the installed versions in %s\n", lockFile)
+	return nil
+}
+
+// installedPackages lists the packages installed with a package manager.
+//
+// Parameters:
+//   - managerName: The name of the package manager.
+//   - managerConfig: The config of the package manager.
+//   - escalation: The program running the list command if it requires root, from the settings.
+//
+// Returns:
+//   - []manager.Record: The installed packages.
+//   - error: An error if the list command has no parse rules, or cannot be run or parsed.
+//
+// This function runs the list command even in dry-run mode, since it does not change the system.
+func installedPackages(managerName string, managerConfig utils.CommandConfig, escalation string) ([]manager.Record, error) {
+	spec, commandTemplate, ok := parsedCommand(managerConfig, listCommand)
+	if !ok {
+		return nil, fmt.Errorf("no parse rule for %s", listCommand)
+	}
+
+	// Run the list command with its output captured
+	var stdout, stderr bytes.Buffer
+	if err := manager.ExecuteCommandTemplate(listCommand, commandTemplate, nil, manager.ExecuteOptions{
+		Batch:      managerConfig.Batch,
+		Quiet:      true,
+		Stdout:     &stdout,
+		Stderr:     &stderr,
+		Escalation: escalation,
+		ForbidRoot: managerConfig.ForbidRoot,
+	}); err != nil {
+		return nil, fmt.Errorf("%v %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return manager.ParseOutput(managerName, spec, stdout.Bytes())
+}
+
+// comparePackages compares the wanted packages with the installed ones, ignoring case.
+//
+// Parameters:
+//   - wanted: The packages declared in the manifest.
+//   - installed: The installed packages.
+//
+// Returns:
+//   - []string: The missing packages, in the order they are wanted.
+//   - []string: The extra packages, installed but not wanted, sorted by name.
+func comparePackages(wanted []string, installed []manager.Record) ([]string, []string) {
+	wantedNames := make(map[string]bool, len(wanted))
+	for _, name := range wanted {
+		wantedNames[strings.ToLower(name)] = true
+	}
+	installedNames := make(map[string]bool, len(installed))
+	var extras []string
+	for _, record := range installed {
+		name := strings.ToLower(record.Package)
+		if installedNames[name] {
+			continue
+		}
+		installedNames[name] = true
+		if !wantedNames[name] {
+			extras = append(extras, record.Package)
+		}
+	}
+	sort.Strings(extras)
+
+	var missing []string
+	for _, name := range wanted {
+		if !installedNames[strings.ToLower(name)] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, extras
+}
+
+// printSyncResults prints a summary table with the result of the synchronization of each package manager.
+//
+// Parameters:
+//   - results: The result of the synchronization for each package manager.
+//   - showExtras: Whether to list the extra packages of each package manager after the table.
+//
+// Example output:
+//
+//	MANAGER  WANTED  MISSING  EXTRAS  RESULT
+//	apt      2       1        412     ok
+//	npm      1       0        1       ok
+func printSyncResults(results []syncResult, showExtras bool) {
+	fmt.Println()
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "MANAGER\tWANTED\tMISSING\tEXTRAS\tRESULT")
+	for _, result := range results {
+		// Show "?" for the extras when the installed packages cannot be listed
+		extras := "?"
+		if result.listed {
+			extras = fmt.Sprint(len(result.extras))
+		}
+		status := result.result
+		if result.err != nil {
+			status = fmt.Sprintf("%s (%v)", status, result.err)
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%s\t%s\n", result.managerName, len(result.wanted), len(result.missing), extras, status)
+	}
+	writer.Flush()
+
+	// List the extra packages of each package manager
+	if showExtras {
+		for _, result := range results {
+			if len(result.extras) > 0 {
+				fmt.Printf("\nExtra packages of %s:\n  %s\n", result.managerName, strings.Join(result.extras, "\n  "))
+			}
+		}
+	}
+}
@@ -11,8 +11,7 @@ import (
 //
 // Parameters:
 //   - managerCmd: The manager command to which the validate command will be added.
-//   - configDir: The directory containing the configuration files for package managers.
-//   - schemaFile: The path to the JSON schema file used for validation.
+//   - paths: The locations of the configuration files for package managers and of the JSON schema file.
 //
 // This function performs the following steps:
 //  1. Creates a new "validate" command.
@@ -22,19 +21,19 @@ import (
 // Example usage:
 //
 //	managerCmd := &cobra.Command{Use: "manager"}
-//	AddValidateCommand(managerCmd, "/path/to/configDir", "/path/to/schemaFile")
+//	AddValidateCommand(managerCmd, paths)
 //
 // This function is useful for validating the configuration files of package managers
 // against a specified JSON schema. It ensures that the configuration files adhere to
 // the defined schema and prints the validation results.
-func AddValidateCommand(managerCmd *cobra.Command, configDir string, schemaFile string) {
+func AddValidateCommand(managerCmd *cobra.Command, paths config.Paths) {
 	// Command to validate JSON files
 	var validateCmd = &cobra.Command{
 		Use:   "validate [managers...]",
 		Short: "Validate package managers config files",
 		Args:  cobra.MinimumNArgs(0),
-		Run: func(cmd *cobra.Command, args []string) {
-			config.ValidateConfigs(args, schemaFile, configDir)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return config.ValidateConfigs(args, paths)
 		},
 	}
 
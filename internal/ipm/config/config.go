@@ -7,47 +7,69 @@ import (
 )
 
 // SetManager enables or disables a package manager based on the provided status.
-// It reads the configuration file, updates the enabled status, and writes the updated configuration back to the file.
+// It reads the configuration file, updates the enabled status, and writes the updated configuration
+// to the directory that configs are written to, overriding the configs of the other sources.
 //
 // Parameters:
 //   - managerName: The name of the package manager to enable or disable.
-//   - configDir: The directory where the configuration files are stored.
+//   - paths: The locations of the configuration files.
 //   - status: A boolean value indicating whether to enable (true) or disable (false) the package manager.
 //
+// Returns:
+//   - error: An error if the configuration file does not exist or cannot be read, updated or written.
+//
 // Example usage:
 //
-//	config.SetManager("apt", "/path/to/config/dir", true)  // Enable the apt package manager
-//	config.SetManager("apt", "/path/to/config/dir", false) // Disable the apt package manager
+//	config.SetManager("apt", paths, true)  // Enable the apt package manager
+//	config.SetManager("apt", paths, false) // Disable the apt package manager
 //
 // This function performs the following steps:
-//  1. Constructs the path to the configuration file for the specified package manager.
-//  2. Checks if the configuration file exists.
-//  3. Reads the content of the configuration file.
-//  4. Unmarshals the JSON data into a CommandConfig struct.
-//  5. Updates the enabled status of the package manager based on the provided status.
-//  6. Marshals the updated CommandConfig struct back into JSON data.
-//  7. Writes the updated JSON data back to the configuration file.
-//  8. Prints a message indicating whether the package manager has been enabled or disabled.
-func SetManager(managerName string, configDir string, status bool) {
-	configFile := filepath.Join(configDir, managerName+".json")
-
-	// Check if the config file exists
-	checkConfigFileExists(configFile)
+//  1. Finds the configuration file for the specified package manager in the highest priority source.
+//  2. Reads the content of the configuration file.
+//  3. Unmarshals the JSON data into a CommandConfig struct.
+//  4. Updates the enabled status of the package manager based on the provided status.
+//  5. Marshals the updated CommandConfig struct back into JSON data.
+//  6. Writes the updated JSON data to the directory that configs are written to.
+//  7. Prints a message indicating whether the package manager has been enabled or disabled.
+func SetManager(managerName string, paths Paths, status bool) error {
+	// Find the config file
+	configFile, err := paths.ConfigFile(managerName)
+	if err != nil {
+		return err
+	}
 
 	// Read the config file
-	data := ReadConfigFile(configFile)
+	data, err := ReadConfigFile(configFile)
+	if err != nil {
+		return err
+	}
 
 	// Unmarshal the config data
-	config := UnmarshalConfig(data, configFile)
+	config, err := UnmarshalConfig(data, configFile.Path())
+	if err != nil {
+		return err
+	}
 
 	// Update the enabled status based on the provided status
 	config.Enabled = status
 
+	// Construct the path to the configuration file in the directory that configs are written to
+	writeDir, err := paths.WriteDir()
+	if err != nil {
+		return err
+	}
+	writeFile := filepath.Join(writeDir, managerName+".json")
+
 	// Marshal the config data
-	newData := marshalConfig(config, configFile)
+	newData, err := marshalConfig(config, writeFile)
+	if err != nil {
+		return err
+	}
 
 	// Write the config file
-	writeConfigFile(configFile, newData)
+	if err := writeConfigFile(writeFile, newData); err != nil {
+		return err
+	}
 
 	// Print a message indicating whether the package manager has been enabled or disabled
 	if status {
@@ -55,4 +77,5 @@ func SetManager(managerName string, configDir string, status bool) {
 	} else {
 		fmt.Printf("Manager %s has been disabled\n", managerName)
 	}
+	return nil
 }
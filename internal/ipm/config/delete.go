@@ -11,29 +11,44 @@ import (
 //
 // Parameters:
 //   - managerName: The name of the package manager whose config is to be deleted.
-//   - configDir: The directory where the configuration files are stored.
+//   - paths: The locations of the configuration files.
+//
+// Returns:
+//   - error: An error if the configuration file does not exist in the directory that configs
+//     are written to or cannot be deleted.
 //
 // Example usage:
 //
-//	config.DeleteManagerConfig("apt", "/path/to/config/dir")
+//	config.DeleteManagerConfig("apt", paths)
 //
 // This function performs the following steps:
-//  1. Constructs the path to the configuration file for the specified package manager.
-//  2. Checks if the configuration file exists.
+//  1. Constructs the path to the configuration file in the directory that configs are written to.
+//  2. Checks if the configuration file exists, and explains when it only exists in another source.
 //  3. Deletes the configuration file.
 //  4. Prints a message indicating that the package manager config has been deleted.
-func DeleteManagerConfig(managerName string, configDir string) {
+func DeleteManagerConfig(managerName string, paths Paths) error {
 	// Construct the path to the configuration file
-	configFile := filepath.Join(configDir, managerName+".json")
+	writeDir, err := paths.WriteDir()
+	if err != nil {
+		return err
+	}
+	configFile := filepath.Join(writeDir, managerName+".json")
 
 	// Check if the config file exists
-	checkConfigFileExists(configFile)
+	if err := checkConfigFileExists(configFile); err != nil {
+		// Configs from the other sources are read-only and can only be disabled
+		if sourceFile, sourceErr := paths.ConfigFile(managerName); sourceErr == nil {
+			return fmt.Errorf("config file %s is read-only, disable the manager instead", sourceFile.Path())
+		}
+		return err
+	}
 
 	// Delete the configuration file
 	if err := os.Remove(configFile); err != nil {
-		fmt.Printf("Failed to delete %s: %v", configFile, err)
+		return fmt.Errorf("failed to delete %s: %v", configFile, err)
 	}
 
 	// Print a message indicating that the package manager config has been deleted
-	fmt.Printf("Manager %s config has been deleted", managerName)
+	fmt.Printf("Manager %s config has been deleted\n", managerName)
+	return nil
 }
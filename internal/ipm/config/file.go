@@ -2,25 +2,31 @@
 package config
 
 import (
-	"log"
 	"os"
+
+	"ipm/internal/ipm/utils"
 )
 
 // checkConfigFileExists checks if the specified configuration file exists.
-// If the file does not exist, it logs a fatal error and terminates the program.
+// If the file does not exist, it returns a ConfigNotFoundError.
 //
 // Parameters:
 //   - configFile: The path to the configuration file to check.
 //
+// Returns:
+//   - error: A utils.ConfigNotFoundError if the file does not exist, or nil otherwise.
+//
 // Example usage:
 //
-//	checkConfigFileExists("/path/to/config.json")
+//	if err := checkConfigFileExists("/path/to/config.json"); err != nil {
+//		return err
+//	}
 //
 // This function is typically used to ensure that a required configuration file
-// is present before attempting to read or write to it. If the file is missing,
-// the program will log an error message and exit, preventing further execution.
-func checkConfigFileExists(configFile string) {
+// is present before attempting to read or write to it.
+func checkConfigFileExists(configFile string) error {
 	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		log.Fatalf("Config file %s does not exist", configFile)
+		return &utils.ConfigNotFoundError{ConfigFile: configFile}
 	}
+	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"ipm/internal/ipm/utils"
@@ -15,29 +16,39 @@ import (
 //
 // Parameters:
 //   - managerName: The name of the package manager for which to generate the config.
-//   - configDir: The directory where the configuration files are stored.
+//   - paths: The locations of the configuration files.
+//
+// Returns:
+//   - error: An error if an answer cannot be read, or if the configuration cannot be
+//     marshalled or written.
 //
 // Example usage:
 //
-//	config.GenerateManagerConfig("apt", "/path/to/config/dir")
+//	config.GenerateManagerConfig("apt", paths)
 //
 // This function performs the following steps:
-//  1. Constructs the path to the configuration file for the specified package manager.
+//  1. Constructs the path to the configuration file in the directory that configs are written to.
 //  2. Initializes a new CommandConfig struct and a map to hold the commands.
 //  3. Prompts the user to enter commands for various package manager operations.
 //  4. Reads the user input and trims any whitespace.
 //  5. Stores the commands in the CommandConfig struct.
-//  6. Prompts the user to enable or disable the package manager.
-//  7. Marshals the CommandConfig struct into JSON data.
-//  8. Writes the JSON data to the configuration file.
-//  9. Prints a message indicating that the package manager config has been generated.
-func GenerateManagerConfig(managerName string, configDir string) {
+//  6. Prompts the user to choose between batched and per-package invocations.
+//  7. Prompts the user to enter the rules used to detect the package manager.
+//  8. Prompts the user to enable or disable the package manager.
+//  9. Marshals the CommandConfig struct into JSON data.
+//  10. Writes the JSON data to the configuration file.
+//  11. Prints a message indicating that the package manager config has been generated.
+func GenerateManagerConfig(managerName string, paths Paths) error {
 	// Construct the path to the configuration file
-	configFile := filepath.Join(configDir, managerName+".json")
+	writeDir, err := paths.WriteDir()
+	if err != nil {
+		return err
+	}
+	configFile := filepath.Join(writeDir, managerName+".json")
 
 	// Initialize a new CommandConfig struct and a map to hold the commands
 	var config utils.CommandConfig
-	config.Commands = make(map[string]string)
+	config.Commands = make(map[string]utils.Command)
 
 	// Create a new reader to read user input from stdin
 	reader := bufio.NewReader(os.Stdin)
@@ -45,59 +56,150 @@ func GenerateManagerConfig(managerName string, configDir string) {
 	// Prompt the user to enter commands for various package manager operations
 	fmt.Printf("Enter command for 'info': ")
 	infoCmd, _ := reader.ReadString('\n')
-	config.Commands["info"] = strings.TrimSpace(infoCmd)
+	config.Commands["info"] = utils.Command{Shell: strings.TrimSpace(infoCmd)}
 
 	fmt.Printf("Enter command for 'install': ")
 	installCmd, _ := reader.ReadString('\n')
-	config.Commands["install"] = strings.TrimSpace(installCmd)
+	config.Commands["install"] = utils.Command{Shell: strings.TrimSpace(installCmd)}
+
+	fmt.Printf("Enter command for 'install-version' (empty if versions cannot be pinned): ")
+	installVersionCmd, _ := reader.ReadString('\n')
+	if installVersionCmd = strings.TrimSpace(installVersionCmd); installVersionCmd != "" {
+		config.Commands["install-version"] = utils.Command{Shell: installVersionCmd}
+	}
 
 	fmt.Printf("Enter command for 'list': ")
 	listInstalledCmd, _ := reader.ReadString('\n')
-	config.Commands["list"] = strings.TrimSpace(listInstalledCmd)
+	config.Commands["list"] = utils.Command{Shell: strings.TrimSpace(listInstalledCmd)}
 
 	fmt.Printf("Enter command for 'search': ")
 	searchCmd, _ := reader.ReadString('\n')
-	config.Commands["search"] = strings.TrimSpace(searchCmd)
+	config.Commands["search"] = utils.Command{Shell: strings.TrimSpace(searchCmd)}
 
 	fmt.Printf("Enter command for 'uninstall': ")
 	uninstallCmd, _ := reader.ReadString('\n')
-	config.Commands["uninstall"] = strings.TrimSpace(uninstallCmd)
+	config.Commands["uninstall"] = utils.Command{Shell: strings.TrimSpace(uninstallCmd)}
 
 	fmt.Printf("Enter command for 'update': ")
 	updateIndexCmd, _ := reader.ReadString('\n')
-	config.Commands["update"] = strings.TrimSpace(updateIndexCmd)
+	config.Commands["update"] = utils.Command{Shell: strings.TrimSpace(updateIndexCmd)}
 
 	fmt.Printf("Enter command for 'upgrade': ")
 	upgradeCmd, _ := reader.ReadString('\n')
-	config.Commands["upgrade"] = strings.TrimSpace(upgradeCmd)
+	config.Commands["upgrade"] = utils.Command{Shell: strings.TrimSpace(upgradeCmd)}
 
 	fmt.Printf("Enter command for 'upgrade-all': ")
 	upgradeAllCmd, _ := reader.ReadString('\n')
-	config.Commands["upgrade-all"] = strings.TrimSpace(upgradeAllCmd)
+	config.Commands["upgrade-all"] = utils.Command{Shell: strings.TrimSpace(upgradeAllCmd)}
+
+	// Prompt the user to choose how multiple packages are passed to the commands
+	if config.Batch, err = promptYesNo(reader, "Pass multiple packages to a single command?"); err != nil {
+		return err
+	}
+
+	// Prompt the user to enter the rules used to detect the package manager
+	config.Detect = promptDetect(reader, managerName)
 
 	// Prompt the user to enable or disable the package manager
-	var enabledInput string
-	for {
-		fmt.Printf("Enable this manager? (yes/no): ")
-		enabledInput, _ = reader.ReadString('\n')
-		enabledInput = strings.ToLower(strings.TrimSpace(enabledInput))
-		if enabledInput == "yes" {
-			config.Enabled = true
-			break
-		} else if enabledInput == "no" {
-			config.Enabled = false
-			break
-		} else {
-			fmt.Println("Invalid input. Please enter 'yes' or 'no'.")
-		}
+	if config.Enabled, err = promptYesNo(reader, "Enable this manager?"); err != nil {
+		return err
 	}
 
 	// Marshal the config data
-	newData := marshalConfig(config, configFile)
+	newData, err := marshalConfig(config, configFile)
+	if err != nil {
+		return err
+	}
 
 	// Write the config file
-	writeConfigFile(configFile, newData)
+	if err := writeConfigFile(configFile, newData); err != nil {
+		return err
+	}
 
 	// Print a message indicating that the package manager config has been generated
-	fmt.Printf("Manager %s config has been generated", managerName)
+	fmt.Printf("Manager %s config has been generated\n", managerName)
+	return nil
+}
+
+// promptYesNo prompts the user with a yes/no question until a valid answer is given.
+//
+// Parameters:
+//   - reader: The reader used to read user input.
+//   - question: The question to display to the user.
+//
+// Returns:
+//   - bool: True if the user answered "yes", false if the user answered "no".
+//   - error: An error if the input cannot be read, e.g. io.EOF when stdin is closed
+//     before a valid answer is given.
+//
+// Example usage:
+//
+//	enabled, err := promptYesNo(reader, "Enable this manager?")
+func promptYesNo(reader *bufio.Reader, question string) (bool, error) {
+	for {
+		fmt.Printf("%s (yes/no): ", question)
+		input, err := reader.ReadString('\n')
+		input = strings.ToLower(strings.TrimSpace(input))
+		if input == "yes" {
+			return true, nil
+		} else if input == "no" {
+			return false, nil
+		}
+
+		// Stop instead of prompting again when no more input can be read
+		if err != nil {
+			fmt.Println()
+			return false, fmt.Errorf("failed to read the answer to %q: %w", question, err)
+		}
+		fmt.Println("Invalid input. Please enter 'yes' or 'no'.")
+	}
+}
+
+// promptDetect prompts the user for the rules used to detect a package manager.
+//
+// Parameters:
+//   - reader: The reader used to read user input.
+//   - managerName: The name of the package manager, used as the default binary.
+//
+// Returns:
+//   - *utils.DetectConfig: The detection rules entered by the user.
+//
+// This function performs the following steps:
+//  1. Prompts the user for the supported operating systems, defaulting to any operating system.
+//  2. Prompts the user for the binary looked up in the PATH, defaulting to the manager name.
+//  3. Prompts the user for the priority until a valid one is given, where an empty priority
+//     means that the package manager is never used as the default package manager.
+func promptDetect(reader *bufio.Reader, managerName string) *utils.DetectConfig {
+	detect := &utils.DetectConfig{}
+
+	// Prompt the user for the supported operating systems
+	fmt.Printf("Enter the supported operating systems, comma-separated (e.g. linux,darwin, empty for any): ")
+	osList, _ := reader.ReadString('\n')
+	for _, goos := range strings.Split(osList, ",") {
+		if goos = strings.ToLower(strings.TrimSpace(goos)); goos != "" {
+			detect.OS = append(detect.OS, goos)
+		}
+	}
+
+	// Prompt the user for the binary looked up in the PATH
+	fmt.Printf("Enter the binary used to detect the manager (empty for %s): ", managerName)
+	binary, _ := reader.ReadString('\n')
+	if binary = strings.TrimSpace(binary); binary != managerName {
+		detect.Binary = binary
+	}
+
+	// Prompt the user for the priority
+	for {
+		fmt.Printf("Enter the detection priority, lowest first (empty to never use it as the default): ")
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+		if input == "" {
+			return detect
+		}
+		if priority, err := strconv.Atoi(input); err == nil && priority > 0 {
+			detect.Priority = priority
+			return detect
+		}
+		fmt.Println("Invalid input. Please enter a positive number.")
+	}
 }
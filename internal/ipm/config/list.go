@@ -3,55 +3,56 @@ package config
 
 import (
 	"fmt"
-	"log"
-	"path/filepath"
-	"strings"
 )
 
 // ListManagers lists package managers based on flags.
 //
 // Parameters:
-//   - configDir: The directory where the configuration files are stored.
+//   - paths: The locations of the configuration files.
 //   - all: A boolean flag indicating whether to list all package managers.
 //   - enabled: A boolean flag indicating whether to list only enabled package managers.
 //   - disabled: A boolean flag indicating whether to list only disabled package managers.
 //
+// Returns:
+//   - error: An error if the configuration files cannot be listed, read or unmarshalled.
+//
 // Example usage:
 //
-//	config.ListManagers("/path/to/config/dir", true, false, false)  // List all package managers
-//	config.ListManagers("/path/to/config/dir", false, true, false)  // List only enabled package managers
-//	config.ListManagers("/path/to/config/dir", false, false, true)  // List only disabled package managers
+//	config.ListManagers(paths, true, false, false)  // List all package managers
+//	config.ListManagers(paths, false, true, false)  // List only enabled package managers
+//	config.ListManagers(paths, false, false, true)  // List only disabled package managers
 //
 // This function performs the following steps:
-//  1. Uses a wildcard to get all JSON files in the specified directory.
-//  2. Checks if each configuration file exists.
-//  3. Reads the content of each configuration file.
-//  4. Unmarshals the JSON data into a CommandConfig struct.
-//  5. Lists the package manager names based on the provided flags.
-func ListManagers(configDir string, all bool, enabled bool, disabled bool) {
-	// Use wildcard to get all JSON files in the specified directory
-	managerFiles, err := filepath.Glob(filepath.Join(configDir, "*.json"))
+//  1. Gets the config file of each package manager from the highest priority source.
+//  2. Reads the content of each configuration file.
+//  3. Unmarshals the JSON data into a CommandConfig struct.
+//  4. Lists the package manager names based on the provided flags, along with the
+//     source each config was loaded from.
+func ListManagers(paths Paths, all bool, enabled bool, disabled bool) error {
+	// Get the config file of each package manager
+	managerFiles, err := paths.ConfigFiles()
 	if err != nil {
-		log.Fatalf("Failed to read manager files: %v", err)
+		return fmt.Errorf("failed to read manager files: %v", err)
 	}
 
 	// Iterate over each manager file
 	for _, managerFile := range managerFiles {
-		// Check if the config file exists
-		checkConfigFileExists(managerFile)
-
 		// Read the config file
-		data := ReadConfigFile(managerFile)
+		data, err := ReadConfigFile(managerFile)
+		if err != nil {
+			return err
+		}
 
 		// Unmarshal the config data
-		config := UnmarshalConfig(data, managerFile)
-
-		// Get the manager name by trimming the file extension
-		managerName := strings.TrimSuffix(filepath.Base(managerFile), ".json")
+		config, err := UnmarshalConfig(data, managerFile.Path())
+		if err != nil {
+			return err
+		}
 
-		// List the manager name based on the provided flags
+		// List the manager name and its source based on the provided flags
 		if all || (enabled && config.Enabled) || (disabled && !config.Enabled) {
-			fmt.Println(managerName)
+			fmt.Printf("%-12s %s\n", managerFile.ManagerName, managerFile.Source.Name)
 		}
 	}
+	return nil
 }
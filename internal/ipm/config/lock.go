@@ -0,0 +1,78 @@
+// Package config provides utilities for managing configuration files
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ipm/internal/ipm/utils"
+)
+
+// LockFile returns the lock file of a manifest.
+//
+// Parameters:
+//   - manifestFile: The path to the manifest file.
+//
+// Returns:
+//   - string: The path to the lock file, next to the manifest with the ".lock" extension.
+//
+// Example usage:
+//
+//	lockFile := config.LockFile("ipm.json") // "ipm.lock"
+func LockFile(manifestFile string) string {
+	return strings.TrimSuffix(manifestFile, filepath.Ext(manifestFile)) + ".lock"
+}
+
+// LoadLock reads a lock file recording the versions of the packages of a manifest.
+//
+// Parameters:
+//   - lockFile: The path to the lock file.
+//
+// Returns:
+//   - utils.Lock: The versions read from the file, or no versions if the file does not exist.
+//   - error: A utils.InvalidConfigError if the file cannot be read or decoded, or nil otherwise.
+//
+// Example usage:
+//
+//	lock, err := config.LoadLock(config.LockFile(config.ManifestFile))
+func LoadLock(lockFile string) (utils.Lock, error) {
+	var lock utils.Lock
+
+	// Read the lock file, which is optional
+	data, err := os.ReadFile(lockFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return lock, nil
+	} else if err != nil {
+		return lock, &utils.InvalidConfigError{ConfigFile: lockFile, Err: err}
+	}
+
+	// Unmarshal the lock data
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return lock, &utils.InvalidConfigError{ConfigFile: lockFile, Err: err}
+	}
+	return lock, nil
+}
+
+// WriteLock writes a lock file recording the versions of the packages of a manifest.
+//
+// Parameters:
+//   - lockFile: The path to the lock file.
+//   - lock: The versions of the packages.
+//
+// Returns:
+//   - error: A utils.InvalidConfigError if the lock cannot be encoded or written, or nil otherwise.
+//
+// Example usage:
+//
+//	err := config.WriteLock("ipm.lock", lock)
+func WriteLock(lockFile string, lock utils.Lock) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return &utils.InvalidConfigError{ConfigFile: lockFile, Err: err}
+	}
+	return writeConfigFile(lockFile, append(data, '\n'))
+}
@@ -0,0 +1,84 @@
+// Package config provides utilities for managing configuration files
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+
+	"ipm/internal/ipm/utils"
+)
+
+// ManifestFile is the default manifest file, looked up in the current directory.
+const ManifestFile = "ipm.json"
+
+// LoadManifest reads a manifest file declaring the packages wanted on a machine.
+//
+// Parameters:
+//   - manifestFile: The path to the manifest file.
+//
+// Returns:
+//   - utils.Manifest: The packages declared in the manifest.
+//   - error: A utils.ManifestNotFoundError if the file does not exist, or a utils.InvalidConfigError
+//     if it cannot be read or decoded, or declares unknown fields.
+//
+// Example usage:
+//
+//	manifest, err := config.LoadManifest(config.ManifestFile)
+func LoadManifest(manifestFile string) (utils.Manifest, error) {
+	var manifest utils.Manifest
+
+	// Read the manifest file
+	data, err := os.ReadFile(manifestFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return manifest, &utils.ManifestNotFoundError{ManifestFile: manifestFile}
+	} else if err != nil {
+		return manifest, &utils.InvalidConfigError{ConfigFile: manifestFile, Err: err}
+	}
+
+	// Unmarshal the manifest data, rejecting misspelled fields
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&manifest); err != nil {
+		return manifest, &utils.InvalidConfigError{ConfigFile: manifestFile, Err: err}
+	}
+	return manifest, nil
+}
+
+// WriteManifest writes a manifest file declaring the packages wanted on a machine.
+//
+// Parameters:
+//   - manifestFile: The path to the manifest file.
+//   - manifest: The packages to declare in the manifest.
+//
+// Returns:
+//   - error: A utils.InvalidConfigError if the manifest cannot be encoded or written, or nil otherwise.
+//
+// Example usage:
+//
+//	err := config.WriteManifest(config.ManifestFile, utils.Manifest{Managers: map[string][]string{"npm": {"typescript"}}})
+func WriteManifest(manifestFile string, manifest utils.Manifest) error {
+	data, err := MarshalManifest(manifest)
+	if err != nil {
+		return &utils.InvalidConfigError{ConfigFile: manifestFile, Err: err}
+	}
+	return writeConfigFile(manifestFile, data)
+}
+
+// MarshalManifest encodes a manifest as indented JSON followed by a newline.
+//
+// Parameters:
+//   - manifest: The packages declared in the manifest.
+//
+// Returns:
+//   - []byte: The JSON data of the manifest.
+//   - error: An error if the manifest cannot be encoded.
+func MarshalManifest(manifest utils.Manifest) ([]byte, error) {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,57 @@
+// Package config provides utilities for managing configuration files
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+
+	"ipm/internal/ipm/utils"
+)
+
+// LoadMapping reads the package name mapping resolving logical names to package ids.
+//
+// Parameters:
+//   - paths: The locations of the configuration files, including the mapping files.
+//
+// Returns:
+//   - utils.PackageMapping: The embedded mapping, overridden by the user's mapping file.
+//   - error: A utils.InvalidConfigError if a mapping cannot be read or decoded, or nil otherwise.
+//
+// Example usage:
+//
+//	mapping, err := config.LoadMapping(paths)
+//
+// This function performs the following steps:
+//  1. Decodes the mapping embedded in the binary.
+//  2. Reads the user's mapping file, which is optional.
+//  3. Overrides the package ids of the embedded mapping with those of the user's mapping.
+func LoadMapping(paths Paths) (utils.PackageMapping, error) {
+	// Decode the embedded mapping
+	mapping := utils.PackageMapping{}
+	if len(paths.mapping) > 0 {
+		if err := json.Unmarshal(paths.mapping, &mapping); err != nil {
+			return nil, &utils.InvalidConfigError{ConfigFile: "embedded:mapping.json", Err: err}
+		}
+	}
+	if paths.MappingFile == "" {
+		return mapping, nil
+	}
+
+	// Read the user's mapping file, which is optional
+	data, err := os.ReadFile(paths.MappingFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return mapping, nil
+	} else if err != nil {
+		return nil, &utils.InvalidConfigError{ConfigFile: paths.MappingFile, Err: err}
+	}
+
+	// Override the embedded mapping with the user's mapping
+	var userMapping utils.PackageMapping
+	if err := json.Unmarshal(data, &userMapping); err != nil {
+		return nil, &utils.InvalidConfigError{ConfigFile: paths.MappingFile, Err: err}
+	}
+	mapping.Merge(userMapping)
+	return mapping, nil
+}
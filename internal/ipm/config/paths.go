@@ -0,0 +1,313 @@
+// Package config provides utilities for managing configuration files
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"ipm/internal/ipm/utils"
+)
+
+// Source represents a location that package manager configs are loaded from.
+//
+// Fields:
+//   - Name: The name of the source, e.g. "user" or "embedded".
+//   - Dir: The directory containing the package manager configs, or an empty string for
+//     the configs embedded in the binary, which are read-only.
+//   - FS: The file system the package manager configs are read from.
+type Source struct {
+	Name string
+	Dir  string
+	FS   fs.FS
+}
+
+// newDirSource creates a source reading the package manager configs from a directory.
+//
+// Parameters:
+//   - name: The name of the source.
+//   - dir: The directory containing the package manager configs.
+//
+// Returns:
+//   - Source: The source reading from the directory.
+func newDirSource(name string, dir string) Source {
+	return Source{Name: name, Dir: dir, FS: os.DirFS(dir)}
+}
+
+// File represents the config file of a package manager found in a source.
+//
+// Fields:
+//   - ManagerName: The name of the package manager.
+//   - Source: The source containing the config file.
+type File struct {
+	ManagerName string
+	Source      Source
+}
+
+// Path returns the path to the config file, used to report it to the user.
+//
+// Returns:
+//   - string: The path to the config file in its directory, or "embedded:<manager>.json"
+//     for the configs embedded in the binary.
+func (f File) Path() string {
+	if f.Source.Dir == "" {
+		return f.Source.Name + ":" + f.ManagerName + ".json"
+	}
+	return filepath.Join(f.Source.Dir, f.ManagerName+".json")
+}
+
+// Paths represents the locations of the package manager configs and of the JSON schema.
+//
+// The configs are searched in several sources, ordered from the highest to the lowest
+// priority. A config found in a source overrides the configs with the same manager name
+// in the sources that follow it, and configs are always written to the first source.
+//
+// Fields:
+//   - Sources: The sources of the configs, ordered from the highest to the lowest priority.
+//   - SchemaFile: The path to the custom JSON schema file used for validation, or an empty
+//     string to use the embedded schema.
+//   - SettingsFile: The path to the settings file of ipm, or an empty string if the user
+//     configuration directory cannot be determined.
+//   - MappingFile: The path to the user's package name mapping, which overrides the embedded
+//     one, or an empty string if the user configuration directory cannot be determined.
+type Paths struct {
+	Sources      []Source
+	SchemaFile   string
+	SettingsFile string
+	MappingFile  string
+	schema       []byte
+	mapping      []byte
+}
+
+// NewPaths creates the paths of the package manager configs and of the JSON schema.
+//
+// Parameters:
+//   - configDir: The custom config directory, or an empty string to search the user and system directories.
+//   - schemaFile: The custom JSON schema file, or an empty string to use the embedded schema.
+//   - embeddedConfigs: The file system containing the configs embedded in the binary.
+//   - embeddedSchema: The JSON schema embedded in the binary.
+//   - embeddedMapping: The package name mapping embedded in the binary.
+//
+// Returns:
+//   - Paths: The paths of the configs and of the JSON schema.
+//
+// Example usage:
+//
+//	paths := config.NewPaths("", "", bundled.ManagerConfigs, bundled.ManagerSchema, bundled.PackageMapping)
+//
+// This function performs the following steps:
+//  1. Uses the custom config directory as the highest priority source if it is provided.
+//  2. Otherwise, uses the user and system config directories, if they can be determined.
+//  3. Uses the configs embedded in the binary as the lowest priority source.
+//  4. Uses the custom JSON schema file if it is provided, or the embedded one otherwise.
+//  5. Uses the settings file and the package name mapping in the user configuration directory.
+func NewPaths(configDir string, schemaFile string, embeddedConfigs fs.FS, embeddedSchema []byte, embeddedMapping []byte) Paths {
+	var sources []Source
+	if configDir != "" {
+		sources = append(sources, newDirSource("custom", configDir))
+	} else {
+		if userDir := userConfigDir(); userDir != "" {
+			sources = append(sources, newDirSource("user", userDir))
+		}
+		if systemDir := systemConfigDir(); systemDir != "" {
+			sources = append(sources, newDirSource("system", systemDir))
+		}
+	}
+	sources = append(sources, Source{Name: "embedded", FS: embeddedConfigs})
+
+	return Paths{
+		Sources:      sources,
+		SchemaFile:   schemaFile,
+		SettingsFile: userFile("settings.json"),
+		MappingFile:  userFile("mapping.json"),
+		schema:       embeddedSchema,
+		mapping:      embeddedMapping,
+	}
+}
+
+// WriteDir returns the directory that configs are written to.
+//
+// Returns:
+//   - string: The directory of the highest priority source.
+//   - error: An error if the highest priority source is not a directory.
+func (p Paths) WriteDir() (string, error) {
+	if p.Sources[0].Dir == "" {
+		return "", errors.New("no writable config directory, use --config-dir to set one")
+	}
+	return p.Sources[0].Dir, nil
+}
+
+// ReadSchema reads the JSON schema used to validate the configs.
+//
+// Returns:
+//   - []byte: The content of the custom JSON schema file, or the embedded schema.
+//   - error: An error if the custom JSON schema file cannot be read.
+func (p Paths) ReadSchema() ([]byte, error) {
+	if p.SchemaFile == "" {
+		return p.schema, nil
+	}
+	return os.ReadFile(p.SchemaFile)
+}
+
+// ConfigFile returns the config file of the specified package manager.
+//
+// Parameters:
+//   - managerName: The name of the package manager.
+//
+// Returns:
+//   - File: The config file in the highest priority source containing it.
+//   - error: A utils.ConfigNotFoundError if no source contains the config file.
+//
+// Example usage:
+//
+//	configFile, err := paths.ConfigFile("apt")
+func (p Paths) ConfigFile(managerName string) (File, error) {
+	for _, source := range p.Sources {
+		if _, err := fs.Stat(source.FS, managerName+".json"); err == nil {
+			return File{ManagerName: managerName, Source: source}, nil
+		}
+	}
+	return File{}, &utils.ConfigNotFoundError{ConfigFile: managerName + ".json"}
+}
+
+// ConfigFiles returns the config files of all package managers.
+//
+// Returns:
+//   - []File: The config file of each package manager, taken from the highest priority
+//     source containing it and sorted by manager name.
+//   - error: An error if a source cannot be read.
+//
+// Example usage:
+//
+//	configFiles, err := paths.ConfigFiles()
+func (p Paths) ConfigFiles() ([]File, error) {
+	configFiles := make(map[string]File)
+	for _, source := range p.Sources {
+		// Use wildcard to get all JSON files in the source
+		sourceFiles, err := fs.Glob(source.FS, "*.json")
+		if err != nil {
+			return nil, err
+		}
+
+		// Keep the config file from the highest priority source
+		for _, sourceFile := range sourceFiles {
+			managerName := ManagerName(sourceFile)
+			if _, ok := configFiles[managerName]; !ok {
+				configFiles[managerName] = File{ManagerName: managerName, Source: source}
+			}
+		}
+	}
+
+	// Sort the config files by manager name
+	managerNames := make([]string, 0, len(configFiles))
+	for managerName := range configFiles {
+		managerNames = append(managerNames, managerName)
+	}
+	sort.Strings(managerNames)
+
+	files := make([]File, 0, len(managerNames))
+	for _, managerName := range managerNames {
+		files = append(files, configFiles[managerName])
+	}
+	return files, nil
+}
+
+// ManagerName returns the name of the package manager of a config file.
+//
+// Parameters:
+//   - configFile: The path to the config file.
+//
+// Returns:
+//   - string: The name of the config file without its extension.
+//
+// Example usage:
+//
+//	managerName := config.ManagerName("apt.json") // "apt"
+func ManagerName(configFile string) string {
+	return strings.TrimSuffix(path.Base(filepath.ToSlash(configFile)), ".json")
+}
+
+// managerDir is the subdirectory of the ipm directories holding the package manager configs.
+const managerDir = "manager"
+
+// userDir returns the ipm directory of the user.
+//
+// Returns:
+//   - string: The "ipm" directory inside $XDG_CONFIG_HOME when it is set on a Unix-like
+//     system, including macOS, or inside the user configuration directory otherwise
+//     (~/.config on Linux, ~/Library/Application Support on macOS, %AppData% on Windows),
+//     or an empty string if it cannot be determined.
+func userDir() string {
+	// Honor XDG_CONFIG_HOME on every Unix-like system, unlike os.UserConfigDir on macOS
+	if xdgConfigHome := os.Getenv("XDG_CONFIG_HOME"); runtime.GOOS != "windows" && filepath.IsAbs(xdgConfigHome) {
+		return filepath.Join(xdgConfigHome, "ipm")
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ipm")
+}
+
+// userConfigDir returns the directory of the user's package manager configs.
+//
+// Returns:
+//   - string: The "manager" subdirectory of the ipm directory of the user, e.g.
+//     ~/.config/ipm/manager, or an empty string if it cannot be determined.
+func userConfigDir() string {
+	dir := userDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, managerDir)
+}
+
+// userFile returns the path to a file of ipm in the user configuration directory.
+//
+// Parameters:
+//   - name: The name of the file, e.g. "settings.json".
+//
+// Returns:
+//   - string: The file inside the ipm directory of the user, e.g. ~/.config/ipm/settings.json,
+//     or an empty string if it cannot be determined.
+func userFile(name string) string {
+	dir := userDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
+
+// systemDir returns the system-wide ipm directory.
+//
+// Returns:
+//   - string: The "ipm" directory inside %ProgramData% on Windows or /etc elsewhere,
+//     or an empty string if it cannot be determined.
+func systemDir() string {
+	if runtime.GOOS == "windows" {
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			return ""
+		}
+		return filepath.Join(programData, "ipm")
+	}
+	return filepath.Join("/etc", "ipm")
+}
+
+// systemConfigDir returns the directory of the system-wide package manager configs.
+//
+// Returns:
+//   - string: The "manager" subdirectory of the system-wide ipm directory, e.g.
+//     /etc/ipm/manager, or an empty string if it cannot be determined.
+func systemConfigDir() string {
+	dir := systemDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, managerDir)
+}
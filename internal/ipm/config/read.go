@@ -3,54 +3,110 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"ipm/internal/ipm/utils"
-	"log"
-	"os"
 )
 
-// ReadConfigFile reads the specified configuration file and returns its content as a byte slice.
-// If the file cannot be read, it logs a fatal error and terminates the program.
+// ReadConfigFile reads the specified configuration file from its source and returns its content as a byte slice.
 //
 // Parameters:
-//   - configFile: The path to the configuration file to read.
+//   - configFile: The configuration file to read.
 //
 // Returns:
 //   - []byte: The content of the configuration file as a byte slice.
+//   - error: A utils.ConfigNotFoundError if the file does not exist, a utils.InvalidConfigError
+//     if it cannot be read, or nil otherwise.
 //
 // Example usage:
 //
-//	data := ReadConfigFile("/path/to/config.json")
+//	data, err := ReadConfigFile(configFile)
 //
 // This function is typically used to read the content of a configuration file
 // before unmarshalling it into a struct or processing it further.
-func ReadConfigFile(configFile string) []byte {
-	data, err := os.ReadFile(configFile)
-	if err != nil {
-		log.Fatalf("Failed to read %s: %v", configFile, err)
+func ReadConfigFile(configFile File) ([]byte, error) {
+	data, err := fs.ReadFile(configFile.Source.FS, configFile.ManagerName+".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &utils.ConfigNotFoundError{ConfigFile: configFile.Path()}
+	} else if err != nil {
+		return nil, &utils.InvalidConfigError{ConfigFile: configFile.Path(), Err: err}
 	}
-	return data
+	return data, nil
 }
 
 // UnmarshalConfig unmarshals the provided JSON data into a CommandConfig struct.
-// If the data cannot be unmarshalled, it logs a fatal error and terminates the program.
 //
 // Parameters:
 //   - data: The JSON data to unmarshal.
-//   - configFile: The path to the configuration file (used for logging purposes).
+//   - configFile: The path to the configuration file (used for error reporting).
 //
 // Returns:
 //   - utils.CommandConfig: The unmarshalled CommandConfig struct.
+//   - error: A utils.InvalidConfigError if the data cannot be unmarshalled, or nil otherwise.
 //
 // Example usage:
 //
-//	config := UnmarshalConfig(data, "/path/to/config.json")
+//	config, err := UnmarshalConfig(data, "/path/to/config.json")
 //
 // This function is typically used to convert JSON data read from a configuration file
 // into a CommandConfig struct for further processing or manipulation.
-func UnmarshalConfig(data []byte, configFile string) utils.CommandConfig {
+func UnmarshalConfig(data []byte, configFile string) (utils.CommandConfig, error) {
 	var config utils.CommandConfig
 	if err := json.Unmarshal(data, &config); err != nil {
-		log.Fatalf("Failed to unmarshal %s: %v", configFile, err)
+		return config, &utils.InvalidConfigError{ConfigFile: configFile, Err: err}
+	}
+	return config, nil
+}
+
+// LoadConfig reads the specified configuration file and unmarshals it into a CommandConfig struct.
+//
+// Parameters:
+//   - configFile: The configuration file to load.
+//
+// Returns:
+//   - utils.CommandConfig: The unmarshalled CommandConfig struct.
+//   - error: A utils.ConfigNotFoundError or utils.InvalidConfigError if the config cannot be loaded.
+//
+// Example usage:
+//
+//	config, err := LoadConfig(configFile)
+func LoadConfig(configFile File) (utils.CommandConfig, error) {
+	data, err := ReadConfigFile(configFile)
+	if err != nil {
+		return utils.CommandConfig{}, err
+	}
+	return UnmarshalConfig(data, configFile.Path())
+}
+
+// LoadConfigs loads the configuration files of all package managers.
+//
+// Parameters:
+//   - paths: The locations of the configuration files.
+//
+// Returns:
+//   - map[string]utils.CommandConfig: A map where the keys are package manager names and the
+//     values are their configs, taken from the highest priority source.
+//   - error: An error if a source cannot be read or a configuration file cannot be loaded.
+//
+// Example usage:
+//
+//	configs, err := LoadConfigs(paths)
+func LoadConfigs(paths Paths) (map[string]utils.CommandConfig, error) {
+	// Get the config file of each package manager
+	configFiles, err := paths.ConfigFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manager files: %v", err)
+	}
+
+	// Load each config file
+	configs := make(map[string]utils.CommandConfig, len(configFiles))
+	for _, configFile := range configFiles {
+		config, err := LoadConfig(configFile)
+		if err != nil {
+			return nil, err
+		}
+		configs[configFile.ManagerName] = config
 	}
-	return config
+	return configs, nil
 }
@@ -0,0 +1,165 @@
+// Package config provides utilities for managing configuration files
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"ipm/internal/ipm/utils"
+)
+
+// LoadSettings reads the settings file of ipm.
+//
+// Parameters:
+//   - paths: The locations of the configuration files, including the settings file.
+//
+// Returns:
+//   - utils.Settings: The settings read from the file, or the zero value if the file does not exist.
+//   - error: A utils.InvalidConfigError if the file cannot be read or decoded, or nil otherwise.
+//
+// Example usage:
+//
+//	settings, err := config.LoadSettings(paths)
+func LoadSettings(paths Paths) (utils.Settings, error) {
+	var settings utils.Settings
+	if paths.SettingsFile == "" {
+		return settings, nil
+	}
+
+	// Read the settings file, which is optional
+	data, err := os.ReadFile(paths.SettingsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return settings, nil
+	} else if err != nil {
+		return settings, &utils.InvalidConfigError{ConfigFile: paths.SettingsFile, Err: err}
+	}
+
+	// Unmarshal the settings data
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, &utils.InvalidConfigError{ConfigFile: paths.SettingsFile, Err: err}
+	}
+	return settings, nil
+}
+
+// saveSettings writes the settings file of ipm, creating its directory if needed.
+//
+// Parameters:
+//   - paths: The locations of the configuration files, including the settings file.
+//   - settings: The settings to write.
+//
+// Returns:
+//   - error: An error if the settings file cannot be determined, encoded or written.
+func saveSettings(paths Paths, settings utils.Settings) error {
+	if paths.SettingsFile == "" {
+		return errors.New("the user configuration directory cannot be determined")
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return &utils.InvalidConfigError{ConfigFile: paths.SettingsFile, Err: err}
+	}
+	return writeConfigFile(paths.SettingsFile, data)
+}
+
+// SetDefaultManager persists the package manager used by the default commands.
+//
+// Parameters:
+//   - managerName: The name of the package manager, or an empty string to clear the setting
+//     and detect the package manager based on the OS again.
+//   - paths: The locations of the configuration files, including the settings file.
+//
+// Returns:
+//   - error: A utils.ConfigNotFoundError if the package manager has no config, or an error
+//     if the settings file cannot be read or written.
+//
+// Example usage:
+//
+//	config.SetDefaultManager("apt", paths) // Use apt for "ipm install"
+//	config.SetDefaultManager("", paths)    // Detect the package manager again
+//
+// This function performs the following steps:
+//  1. Checks that the package manager has a config in one of the sources.
+//  2. Reads the current settings.
+//  3. Updates the default package manager and writes the settings file.
+//  4. Prints a message indicating the new default package manager.
+func SetDefaultManager(managerName string, paths Paths) error {
+	// Check that the package manager has a config
+	if managerName != "" {
+		if _, err := paths.ConfigFile(managerName); err != nil {
+			return err
+		}
+	}
+
+	// Read the current settings
+	settings, err := LoadSettings(paths)
+	if err != nil {
+		return err
+	}
+
+	// Update the default package manager and write the settings
+	settings.Default = managerName
+	if err := saveSettings(paths, settings); err != nil {
+		return err
+	}
+
+	// Print a message indicating the new default package manager
+	if managerName == "" {
+		fmt.Println("Default manager has been cleared")
+	} else {
+		fmt.Printf("Default manager has been set to %s\n", managerName)
+	}
+	return nil
+}
+
+// SetEscalation persists the program used to run the commands that require root.
+//
+// Parameters:
+//   - escalation: The escalation program, one of utils.EscalationPrograms, or an empty string
+//     to clear the setting and use sudo or doas, whichever is installed.
+//   - paths: The locations of the configuration files, including the settings file.
+//
+// Returns:
+//   - error: An error if the escalation program is not supported, or if the settings file
+//     cannot be read or written.
+//
+// Example usage:
+//
+//	config.SetEscalation("doas", paths) // Run the commands that require root with doas
+//	config.SetEscalation("", paths)     // Detect sudo or doas again
+//
+// This function performs the following steps:
+//  1. Checks that the escalation program is supported.
+//  2. Reads the current settings.
+//  3. Updates the escalation program and writes the settings file.
+//  4. Prints a message indicating the new escalation program.
+func SetEscalation(escalation string, paths Paths) error {
+	// Check that the escalation program is supported
+	if escalation != "" && !slices.Contains(utils.EscalationPrograms, escalation) {
+		return fmt.Errorf("invalid escalation %s, must be one of %s", escalation, strings.Join(utils.EscalationPrograms, ", "))
+	}
+
+	// Read the current settings
+	settings, err := LoadSettings(paths)
+	if err != nil {
+		return err
+	}
+
+	// Update the escalation program and write the settings
+	settings.Escalation = escalation
+	if err := saveSettings(paths, settings); err != nil {
+		return err
+	}
+
+	// Print a message indicating the new escalation program
+	if escalation == "" {
+		fmt.Println("Escalation has been cleared")
+	} else {
+		fmt.Printf("Escalation has been set to %s\n", escalation)
+	}
+	return nil
+}
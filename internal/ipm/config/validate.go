@@ -2,9 +2,8 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"ipm/internal/ipm/utils"
 )
@@ -13,47 +12,112 @@ import (
 //
 // Parameters:
 //   - args: A slice of strings containing the names of the package managers to validate.
-//   - schemaFile: The path to the JSON schema file used for validation.
-//   - configDir: The directory where the configuration files are stored.
+//   - paths: The locations of the configuration files and of the JSON schema file used for validation.
+//
+// Returns:
+//   - error: The joined errors of every configuration file that does not exist or fails
+//     validation, or nil if all the configuration files are valid.
 //
 // This function performs the following steps:
 //  1. If specific package manager names are provided in args, it validates only those configuration files.
-//  2. If no package manager names are provided, it validates all configuration files in the configDir.
-//  3. For each configuration file, it checks if the file exists.
+//  2. If no package manager names are provided, it validates the configuration files of all package managers.
+//  3. For each configuration file, it finds the file in the highest priority source.
 //  4. If the file exists, it validates the file against the provided JSON schema.
-//  5. It prints the validation result for each configuration file.
-func ValidateConfigs(args []string, schemaFile string, configDir string) {
+//  5. It prints the successful validation results and returns the failed ones.
+func ValidateConfigs(args []string, paths Paths) error {
 	// Check if specific package manager names are provided in args
 	if len(args) > 0 {
+		// Read the JSON schema used for validation
+		schema, err := paths.ReadSchema()
+		if err != nil {
+			return fmt.Errorf("failed to read schema: %v", err)
+		}
+
+		// Create a slice to collect validation errors
+		var validationErrors []error
+
 		// Iterate over each package manager name provided in args
 		for _, managerName := range args {
-			// Construct the path to the configuration file for the specified package manager
-			configFile := filepath.Join(configDir, managerName+".json")
-
-			// Check if the configuration file exists
-			if _, err := os.Stat(configFile); os.IsNotExist(err) {
-				// Print a message if the configuration file does not exist
-				fmt.Printf("Validation failed for %s: config file does not exist\n", configFile)
+			// Find the configuration file for the specified package manager
+			configFile, err := paths.ConfigFile(managerName)
+			if err != nil {
+				// Collect the error if the configuration file does not exist
+				validationErrors = append(validationErrors, err)
 				continue
 			}
 
 			// Validate the configuration file against the provided JSON schema
-			if err := utils.ValidateJSONFile(schemaFile, configFile); err == nil {
+			if err := validateConfigFile(schema, configFile); err == nil {
 				// Print a message if the validation is successful
-				fmt.Printf("Validation successful for %s\n", configFile)
+				fmt.Printf("Validation successful for %s\n", configFile.Path())
 			} else {
-				// Print a message if there is a validation error
-				fmt.Printf("Validation error: %v\n", err)
+				// Collect the error if there is a validation error
+				validationErrors = append(validationErrors, err)
 			}
 		}
-	} else {
-		// Validate all configuration files in the configDir if no specific package manager names are provided
-		if err := utils.ValidateJSONFiles(schemaFile, configDir); err == nil {
-			// Print a message if the validation is successful for all configuration files
-			fmt.Println("Validation successful for all configs")
-		} else {
-			// Print the validation errors if any
-			fmt.Printf("%v", err)
+
+		// Return the joined validation errors, or nil if there are none
+		return errors.Join(validationErrors...)
+	}
+
+	// Validate the configuration files of all package managers if no specific package manager names are provided
+	if err := ValidateConfigFiles(paths); err != nil {
+		return err
+	}
+
+	// Print a message if the validation is successful for all configuration files
+	fmt.Println("Validation successful for all configs")
+	return nil
+}
+
+// ValidateConfigFiles validates the configuration files of all package managers without printing the results.
+//
+// Parameters:
+//   - paths: The locations of the configuration files and of the JSON schema file used for validation.
+//
+// Returns:
+//   - error: The joined errors of every configuration file that fails validation, or nil if all are valid.
+//
+// Example usage:
+//
+//	if err := config.ValidateConfigFiles(paths); err != nil {
+//		return err
+//	}
+func ValidateConfigFiles(paths Paths) error {
+	// Read the JSON schema used for validation
+	schema, err := paths.ReadSchema()
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %v", err)
+	}
+
+	// Get the config file of each package manager
+	configFiles, err := paths.ConfigFiles()
+	if err != nil {
+		return fmt.Errorf("failed to read manager files: %v", err)
+	}
+
+	// Validate each config file against the schema and collect the errors
+	var validationErrors []error
+	for _, configFile := range configFiles {
+		if err := validateConfigFile(schema, configFile); err != nil {
+			validationErrors = append(validationErrors, err)
 		}
 	}
+	return errors.Join(validationErrors...)
+}
+
+// validateConfigFile validates a configuration file against the JSON schema.
+//
+// Parameters:
+//   - schema: The content of the JSON schema.
+//   - configFile: The configuration file to validate.
+//
+// Returns:
+//   - error: An error if the configuration file cannot be read or fails validation, or nil if it is valid.
+func validateConfigFile(schema []byte, configFile File) error {
+	data, err := ReadConfigFile(configFile)
+	if err != nil {
+		return err
+	}
+	return utils.ValidateJSON(schema, data, configFile.Path())
 }
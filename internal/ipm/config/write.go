@@ -4,49 +4,56 @@ package config
 import (
 	"encoding/json"
 	"ipm/internal/ipm/utils"
-	"log"
 	"os"
+	"path/filepath"
 )
 
 // marshalConfig marshals the CommandConfig struct into JSON data.
-// If the struct cannot be marshalled, it logs a fatal error and terminates the program.
 //
 // Parameters:
 //   - config: The CommandConfig struct to marshal.
-//   - configFile: The path to the configuration file (used for logging purposes).
+//   - configFile: The path to the configuration file (used for error reporting).
 //
 // Returns:
 //   - []byte: The marshalled JSON data.
+//   - error: A utils.InvalidConfigError if the struct cannot be marshalled, or nil otherwise.
 //
 // Example usage:
 //
-//	data := marshalConfig(config, "/path/to/config.json")
+//	data, err := marshalConfig(config, "/path/to/config.json")
 //
 // This function is typically used to convert a CommandConfig struct into JSON data
 // before writing it to a configuration file.
-func marshalConfig(config utils.CommandConfig, configFile string) []byte {
+func marshalConfig(config utils.CommandConfig, configFile string) ([]byte, error) {
 	newData, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
-		log.Fatalf("Failed to marshal %s: %v", configFile, err)
+		return nil, &utils.InvalidConfigError{ConfigFile: configFile, Err: err}
 	}
-	return newData
+	return newData, nil
 }
 
-// writeConfigFile writes the JSON data to the specified configuration file.
-// If the file cannot be written, it logs a fatal error and terminates the program.
+// writeConfigFile writes the JSON data to the specified configuration file, creating its
+// directory if needed.
 //
 // Parameters:
 //   - configFile: The path to the configuration file to write.
 //   - data: The JSON data to write to the file.
 //
+// Returns:
+//   - error: A utils.InvalidConfigError if the file cannot be written, or nil otherwise.
+//
 // Example usage:
 //
-//	writeConfigFile("/path/to/config.json", data)
+//	err := writeConfigFile("/path/to/config.json", data)
 //
 // This function is typically used to write JSON data to a configuration file
 // after marshalling a struct or processing the data in some way.
-func writeConfigFile(configFile string, data []byte) {
+func writeConfigFile(configFile string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return &utils.InvalidConfigError{ConfigFile: configFile, Err: err}
+	}
 	if err := os.WriteFile(configFile, data, 0644); err != nil {
-		log.Fatalf("Failed to write %s: %v", configFile, err)
+		return &utils.InvalidConfigError{ConfigFile: configFile, Err: err}
 	}
+	return nil
 }
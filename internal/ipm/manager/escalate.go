@@ -0,0 +1,133 @@
+// Package manager provides utilities for executing command templates and running commands
+package manager
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+
+	"ipm/internal/ipm/utils"
+)
+
+// detectedEscalationPrograms are the programs looked up, in order, when the escalation
+// setting is not set.
+var detectedEscalationPrograms = []string{utils.EscalationSudo, utils.EscalationDoas}
+
+// isRoot reports whether ipm runs as root.
+//
+// Returns:
+//   - bool: True if the effective user is root on a Unix-like system, false otherwise,
+//     including on Windows where privileges are not escalated.
+func isRoot() bool {
+	return runtime.GOOS != "windows" && os.Geteuid() == 0
+}
+
+// escalates reports whether the commands that require root are run with an escalation program.
+//
+// Parameters:
+//   - escalation: The escalation setting, one of the utils.Escalation constants, or an
+//     empty string to use the first of sudo and doas that is installed.
+//
+// Returns:
+//   - bool: False if ipm already runs as root, privileges are not escalated on Windows, or
+//     the setting is utils.EscalationNone, true otherwise.
+func escalates(escalation string) bool {
+	return !isRoot() && runtime.GOOS != "windows" && escalation != utils.EscalationNone
+}
+
+// RequiresEscalation reports whether a command is run with an escalation program, which
+// may prompt for a password.
+//
+// Parameters:
+//   - commandTemplate: The command of the package manager config.
+//   - escalation: The escalation setting, one of the utils.Escalation constants, or an
+//     empty string to use the first of sudo and doas that is installed.
+//
+// Returns:
+//   - bool: True if the command requires root and ipm escalates its privileges.
+//
+// Example usage:
+//
+//	if manager.RequiresEscalation(aptConfig.Commands["install"], "") {
+//		// Run apt alone so that sudo prompts for a password without interleaving
+//	}
+func RequiresEscalation(commandTemplate utils.Command, escalation string) bool {
+	return commandTemplate.RequiresRoot && escalates(escalation)
+}
+
+// escalationProgram returns the program used to run a command that requires root.
+//
+// Parameters:
+//   - command: The name of the command, used in the error message.
+//   - escalation: The escalation setting, one of the utils.Escalation constants, or an
+//     empty string to use the first of sudo and doas that is installed.
+//
+// Returns:
+//   - string: The escalation program, or an empty string to run the command as is because
+//     ipm already runs as root, privileges are not escalated on Windows, or the setting
+//     is utils.EscalationNone.
+//   - error: A utils.PermissionError if the setting is not set and neither sudo nor doas
+//     is installed.
+//
+// Example usage:
+//
+//	program, err := escalationProgram("install", "") // "sudo"
+func escalationProgram(command string, escalation string) (string, error) {
+	// Run the command as is if it cannot or does not need to be escalated
+	if !escalates(escalation) {
+		return "", nil
+	}
+	if escalation != "" {
+		return escalation, nil
+	}
+
+	// Use the first escalation program that is installed
+	if program := DetectEscalationProgram(); program != "" {
+		return program, nil
+	}
+	return "", &utils.PermissionError{
+		Command: command,
+		Reason:  "it requires root but neither sudo nor doas is installed, run ipm as root or set the escalation with \"ipm manager escalation\"",
+	}
+}
+
+// DetectEscalationProgram returns the escalation program used when the escalation setting
+// is not set.
+//
+// Returns:
+//   - string: The first of sudo and doas that is installed, or an empty string if neither is.
+//
+// Example usage:
+//
+//	program := manager.DetectEscalationProgram() // "sudo"
+func DetectEscalationProgram() string {
+	for _, program := range detectedEscalationPrograms {
+		if _, err := exec.LookPath(program); err == nil {
+			return program
+		}
+	}
+	return ""
+}
+
+// escalate prefixes a final command with the program escalating its privileges.
+//
+// Parameters:
+//   - finalCmd: The rendered command.
+//   - program: The escalation program, e.g. "sudo", or an empty string to leave the command as is.
+//
+// Returns:
+//   - finalCommand: The argument list running the command with the escalation program,
+//     through "sh -c" for shell commands.
+//
+// Example usage:
+//
+//	finalCmd = escalate(finalCommand{shell: "apt-get install -y jq"}, "sudo") // sudo sh -c 'apt-get install -y jq'
+func escalate(finalCmd finalCommand, program string) finalCommand {
+	if program == "" {
+		return finalCmd
+	}
+	if finalCmd.argv == nil {
+		return finalCommand{argv: []string{program, "sh", "-c", finalCmd.shell}}
+	}
+	return finalCommand{argv: append([]string{program}, finalCmd.argv...)}
+}
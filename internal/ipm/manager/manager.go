@@ -3,44 +3,225 @@ package manager
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"os/exec"
-	"runtime"
+	"strings"
 	"text/template"
+
+	"ipm/internal/ipm/utils"
 )
 
+// ExecuteOptions holds the options that control how a command template is executed.
+//
+// Fields:
+//   - Batch: Renders a single invocation for all packages instead of one invocation per package.
+//   - DryRun: Prints the final command without running it.
+//   - Fallback: The behavior when the command is not available, utils.FallbackError or utils.FallbackWarn.
+//   - Quiet: Does not print the final command before running it, e.g. when its output is parsed.
+//   - Version: The version the package is pinned to, rendered as .Version by commands such as
+//     install-version, or an empty string.
+//   - Stdout: The writer receiving the standard output of the command and the messages of ipm,
+//     or nil to use os.Stdout.
+//   - Stderr: The writer receiving the standard error of the command and the warnings of ipm,
+//     or nil to use os.Stderr.
+//   - Escalation: The program running the commands that require root, one of the
+//     utils.Escalation constants, or an empty string to use sudo or doas, whichever is installed.
+//   - ForbidRoot: Refuses to run the command when ipm runs as root.
+type ExecuteOptions struct {
+	Batch      bool
+	DryRun     bool
+	Fallback   string
+	Quiet      bool
+	Version    string
+	Stdout     io.Writer
+	Stderr     io.Writer
+	Escalation string
+	ForbidRoot bool
+}
+
+// stdout returns the writer receiving the standard output, os.Stdout by default.
+func (o ExecuteOptions) stdout() io.Writer {
+	if o.Stdout == nil {
+		return os.Stdout
+	}
+	return o.Stdout
+}
+
+// stderr returns the writer receiving the standard error, os.Stderr by default.
+func (o ExecuteOptions) stderr() io.Writer {
+	if o.Stderr == nil {
+		return os.Stderr
+	}
+	return o.Stderr
+}
+
 // ExecuteCommandTemplate executes a command template with the given parameters.
 //
 // Parameters:
 //   - command: The base command to execute.
-//   - templateStr: The command template string to parse and execute.
+//   - commandTemplate: The command template to parse and execute, either a shell command or an argument list.
 //   - params: A slice of strings containing the parameters to pass to the template.
+//   - options: The options controlling batching, dry-run, fallback behavior and output streams.
+//
+// Returns:
+//   - error: A utils.CommandUnavailableError if the command is not available and has no fallback,
+//     a utils.PermissionError if the command must not or cannot be run with the privileges of ipm,
+//     an error if the command cannot be rendered, or a utils.CommandFailedError if it cannot be run.
 //
 // Example usage:
 //
-//	ExecuteCommandTemplate("apt-get install -y", "{{.Package}}", []string{"jq"})
+//	err := ExecuteCommandTemplate("install", utils.Command{Shell: "apt-get install -y {{.Package}}"}, []string{"jq", "git"}, ExecuteOptions{Batch: true})
 //
 // This function performs the following steps:
-//  1. Parses the command template with the given parameters using the parseCommandTemplate function.
-//  2. Checks if the final command string is empty and prints a message if the command is not available.
-//  3. Prints the final command string to be executed.
-//  4. Runs the command using the final command string by calling the runCommand function.
-func ExecuteCommandTemplate(command string, templateStr string, params []string) {
-	// Parse and execute the command template
-	finalCmdStr := parseCommandTemplate(templateStr, params)
-
-	// Checks if the final command string is empty
-	if finalCmdStr == "" {
-		// Prints a message if the command is not available
-		fmt.Printf("Executing %s: %s\n", command, "Command not available")
-	} else {
-		// Prints the final command string to be executed
-		fmt.Printf("Executing %s: %s\n", command, finalCmdStr)
+//  1. Checks if the command is available, and either returns an error or prints a warning if it is not.
+//  2. Refuses to run the command as root if options.ForbidRoot is set.
+//  3. Finds the escalation program if the command requires root, rendering sudo as a
+//     placeholder in dry-run mode when none is installed.
+//  4. Groups the parameters into invocations, either one for all packages or one per package.
+//  5. Renders the command template for each invocation using the renderCommand function,
+//     prefixed with the escalation program.
+//  6. Prints the final command to be executed, unless options.Quiet is set.
+//  7. Runs the final command by calling the runCommand function, unless options.DryRun is set.
+//  8. Stops at the first invocation that fails.
+func ExecuteCommandTemplate(command string, commandTemplate utils.Command, params []string, options ExecuteOptions) error {
+	// Checks if the command is available
+	if !commandTemplate.IsAvailable() {
+		// Prints a warning and does nothing if the command falls back to a no-op
+		if options.Fallback == utils.FallbackWarn {
+			fmt.Fprintf(options.stderr(), "Warning: command %s is not available, skipping\n", command)
+			return nil
+		}
+		return &utils.CommandUnavailableError{Command: command}
+	}
+
+	// Refuse to run the package managers that must not run as root
+	if options.ForbidRoot && isRoot() && !options.DryRun {
+		return &utils.PermissionError{Command: command, Reason: "the package manager must not be run as root"}
+	}
+
+	// Find the escalation program of the commands that require root
+	var escalation string
+	if commandTemplate.RequiresRoot {
+		var err error
+		if escalation, err = escalationProgram(command, options.Escalation); err != nil {
+			if !options.DryRun {
+				return err
+			}
+			// Render sudo as a placeholder in dry-run mode, since nothing runs
+			escalation = utils.EscalationSudo
+		}
+	}
+
+	// Group the parameters into one invocation per package unless batching is requested
+	invocations := [][]string{params}
+	if !options.Batch && len(params) > 1 {
+		invocations = make([][]string, 0, len(params))
+		for _, param := range params {
+			invocations = append(invocations, []string{param})
+		}
+	}
+
+	for _, invocationParams := range invocations {
+		// Render the command template
+		finalCmd, err := renderCommand(commandTemplate, invocationParams, options.Version)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %v", command, err)
+		}
+		finalCmd = escalate(finalCmd, escalation)
+
+		// Prints the final command without running it in dry-run mode
+		if options.DryRun {
+			fmt.Fprintf(options.stdout(), "Dry run %s: %s\n", command, finalCmd)
+			continue
+		}
+
+		// Prints the final command to be executed
+		if !options.Quiet {
+			fmt.Fprintf(options.stdout(), "Executing %s: %s\n", command, finalCmd)
+		}
 		// Run the command
-		runCommand(command, finalCmdStr)
+		if err := runCommand(command, finalCmd, options.stdout(), options.stderr()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finalCommand represents a rendered command ready to be executed.
+//
+// Fields:
+//   - shell: The command string run through the shell, for shell commands.
+//   - argv: The arguments executed directly, for argument lists.
+type finalCommand struct {
+	shell string
+	argv  []string
+}
+
+// String returns the final command as it would be typed in a POSIX shell.
+func (c finalCommand) String() string {
+	if c.argv == nil {
+		return c.shell
+	}
+	quoted := make([]string, 0, len(c.argv))
+	for _, arg := range c.argv {
+		quoted = append(quoted, quotePOSIXArgument(arg))
 	}
+	return strings.Join(quoted, " ")
+}
+
+// renderCommand renders a command template with the given parameters.
+//
+// Parameters:
+//   - commandTemplate: The command template to render, either a shell command or an argument list.
+//   - params: A slice of strings containing the parameters to pass to the template.
+//   - version: The version the package is pinned to, or an empty string.
+//
+// Returns:
+//   - finalCommand: The rendered shell command or argument list.
+//   - error: An error if the command template cannot be parsed or executed, an element of an
+//     argument list renders several packages along with other text, or the argument list
+//     renders no argument at all.
+//
+// Example usage:
+//
+//	finalCmd, err := renderCommand(utils.Command{Argv: []string{"brew", "install", "{{.Package}}"}}, []string{"jq", "git"}, "")
+//
+// This function performs the following steps:
+//  1. Renders shell commands as a single string, quoting the parameters for the shell.
+//  2. Renders each element of an argument list separately, passing the parameters verbatim.
+//  3. Splits the rendered elements so that each parameter of a list becomes its own argument,
+//     rejecting the elements that embed a list in a larger argument, e.g. "--pkg={{.Packages}}".
+//  4. Drops the elements that only rendered empty parameters.
+//  5. Rejects the argument lists that rendered no argument, e.g. ["{{.Packages}}"] without packages.
+func renderCommand(commandTemplate utils.Command, params []string, version string) (finalCommand, error) {
+	// Render shell commands as a single string
+	if len(commandTemplate.Argv) == 0 {
+		shell, err := parseCommandTemplate(commandTemplate.Shell, params, version, shellFormat)
+		return finalCommand{shell: shell}, err
+	}
+
+	// Render each element of the argument list separately
+	argv := make([]string, 0, len(commandTemplate.Argv))
+	for _, argTemplate := range commandTemplate.Argv {
+		arg, err := parseCommandTemplate(argTemplate, params, version, argvFormat)
+		if err != nil {
+			return finalCommand{}, err
+		}
+		if arg == "" && argTemplate != "" {
+			continue
+		}
+		if strings.Contains(arg, argvSeparator) && arg != strings.Join(params, argvSeparator) {
+			return finalCommand{}, fmt.Errorf("argument %q renders several packages within a larger argument, make the list a whole element or use join", argTemplate)
+		}
+		argv = append(argv, strings.Split(arg, argvSeparator)...)
+	}
+	if len(argv) == 0 {
+		return finalCommand{}, errors.New("the argument list renders no argument")
+	}
+	return finalCommand{argv: argv}, nil
 }
 
 // parseCommandTemplate parses and executes the command template with the given parameters.
@@ -48,76 +229,90 @@ func ExecuteCommandTemplate(command string, templateStr string, params []string)
 // Parameters:
 //   - templateStr: The command template string to parse and execute.
 //   - params: A slice of strings containing the parameters to pass to the template.
+//   - version: The version the package is pinned to, or an empty string.
+//   - format: The format used to render the parameters, shellFormat or argvFormat.
 //
 // Returns:
 //   - string: The final command string after parsing and executing the template.
+//   - error: An error if the template cannot be parsed or executed.
 //
 // Example usage:
 //
-//	finalCmdStr := parseCommandTemplate("{{.Package}}!", []string{"jq"})
+//	finalCmdStr, err := parseCommandTemplate("brew install {{join .Packages \" \"}}", []string{"jq", "git"}, "", shellFormat)
 //
 // This function performs the following steps:
-//  1. Parses the command template using the provided template string.
-//  2. Creates a map for template data and populates it with the provided parameters.
+//  1. Parses the command template using the provided template string and the template functions.
+//  2. Creates the template data from the provided parameters in the provided format.
 //  3. Executes the template with the provided data and stores the result in a buffer.
 //  4. Returns the final command string from the buffer.
-func parseCommandTemplate(templateStr string, params []string) string {
+func parseCommandTemplate(templateStr string, params []string, version string, format *paramFormat) (string, error) {
 	// Parse the command template
-	tmpl, err := template.New("command").Parse(templateStr)
+	tmpl, err := template.New("command").Funcs(templateFuncs).Parse(templateStr)
 	if err != nil {
-		log.Fatalf("Failed to parse command template: %v", err)
+		return "", fmt.Errorf("failed to parse command template: %v", err)
 	}
 
-	// Create a map for template data
-	templateData := make(map[string]string)
-	if len(params) > 0 {
-		// If there are parameters, use the first one as the "Package" value
-		templateData["Package"] = params[0]
-	}
+	// Create the template data
+	templateData := newTemplateData(params, version, format)
 
 	// Buffer to hold the executed template result
 	var cmdBuffer bytes.Buffer
 
 	// Execute the template with the provided data
 	if err := tmpl.Execute(&cmdBuffer, templateData); err != nil {
-		log.Fatalf("Failed to execute command template: %v", err)
+		return "", fmt.Errorf("failed to execute command template: %v", err)
 	}
 
 	// Get the final command string from the buffer
-	return cmdBuffer.String()
+	return cmdBuffer.String(), nil
 }
 
-// runCommand creates and runs the command, capturing and printing the output.
+// runCommand creates and runs the command, streaming its output to the given writers.
 //
 // Parameters:
 //   - command: The base command to execute.
-//   - finalCmdStr: The final command string to execute.
+//   - finalCmd: The final command to execute.
+//   - stdout: The writer receiving the standard output of the command.
+//   - stderr: The writer receiving the standard error of the command.
+//
+// Returns:
+//   - error: A utils.CommandFailedError carrying the exit status of the package manager
+//     if the command cannot be started or fails, or nil otherwise.
 //
 // Example usage:
 //
-//	runCommand("apt-get install -y", "jq")
+//	err := runCommand("install", finalCommand{argv: []string{"apt-get", "install", "-y", "jq"}}, os.Stdout, os.Stderr)
 //
 // This function performs the following steps:
-//  1. Creates the command to be executed based on the operating system.
-//  2. Set the output streams to directly stream the output.
+//  1. Creates the command to be executed, directly for argument lists or through the
+//     shell of the operating system for shell commands.
+//  2. Set the output streams to the given writers.
 //  3. Run the command.
-func runCommand(command string, finalCmdStr string) {
+//  4. Wraps any failure with the exit status of the command.
+func runCommand(command string, finalCmd finalCommand, stdout io.Writer, stderr io.Writer) error {
 	// Create the command to be executed
 	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		// On Windows, use "cmd /C" to execute the command
-		cmd = exec.Command("cmd", "/C", finalCmdStr)
+	if finalCmd.argv != nil {
+		// Execute argument lists directly without an intermediate shell
+		cmd = exec.Command(finalCmd.argv[0], finalCmd.argv[1:]...)
 	} else {
-		// On Unix-like systems, use "sh -c" to execute the command
-		cmd = exec.Command("sh", "-c", finalCmdStr)
+		// Execute shell commands with "cmd /S /C" on Windows or "sh -c" on Unix-like systems
+		cmd = shellCommand(finalCmd.shell)
 	}
 
-	// Set the output streams to directly stream the output
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// Set the output streams to the given writers
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
 	// Run the command
 	if err := cmd.Run(); err != nil {
-		log.Fatalf("Failed to execute %s: %v", command, err)
+		// Use the exit status of the command if it ran, or -1 if it could not be started
+		exitCode := -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+		return &utils.CommandFailedError{Command: command, ExitCode: exitCode, Err: err}
 	}
+	return nil
 }
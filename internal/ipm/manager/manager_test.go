@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"slices"
+	"testing"
+
+	"ipm/internal/ipm/utils"
+)
+
+func TestRenderCommandArgv(t *testing.T) {
+	tests := []struct {
+		name    string
+		argv    []string
+		params  []string
+		want    []string
+		wantErr bool
+	}{
+		{"one argument per package", []string{"brew", "install", "{{.Package}}"}, []string{"jq", "foo; rm -rf ~"}, []string{"brew", "install", "jq", "foo; rm -rf ~"}, false},
+		{"list element", []string{"pip", "install", "{{.Packages}}"}, []string{"a", "b"}, []string{"pip", "install", "a", "b"}, false},
+		{"single package in a larger element", []string{"pkg", "--pkg={{.Packages}}"}, []string{"a"}, []string{"pkg", "--pkg=a"}, false},
+		{"joined packages", []string{"pkg", `--pkg={{join .Packages ","}}`}, []string{"a", "b"}, []string{"pkg", "--pkg=a,b"}, false},
+		{"empty packages dropped", []string{"apt-get", "update", "{{.Packages}}"}, nil, []string{"apt-get", "update"}, false},
+		{"list in a larger element", []string{"pkg", "--pkg={{.Packages}}"}, []string{"a", "b"}, nil, true},
+		{"no argument", []string{"{{.Packages}}"}, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderCommand(utils.Command{Argv: tt.argv}, tt.params, "")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("renderCommand() = %q, want an error", got.argv)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderCommand() error = %v", err)
+			}
+			if !slices.Equal(got.argv, tt.want) {
+				t.Errorf("renderCommand() = %q, want %q", got.argv, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+// Package manager provides utilities for executing command templates and running commands
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"ipm/internal/ipm/utils"
+)
+
+// jsonKeyField is the value of JSONSpec.Package that uses the keys of an object as package names.
+const jsonKeyField = "$key"
+
+// Record represents a package parsed from the output of a package manager command.
+//
+// Fields:
+//   - Manager: The name of the package manager that printed the package.
+//   - Package: The name or identifier of the package.
+//   - Version: The version of the package, if the output contains it.
+//   - Description: The description of the package, if the output contains it.
+type Record struct {
+	Manager     string `json:"manager"`
+	Package     string `json:"package"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+// set sets a field of the record by its name.
+//
+// Parameters:
+//   - field: The name of the field, "package", "version" or "description". Other names are ignored.
+//   - value: The value of the field, trimmed of surrounding whitespace.
+func (r *Record) set(field string, value string) {
+	value = strings.TrimSpace(value)
+	switch field {
+	case "package":
+		r.Package = value
+	case "version":
+		r.Version = value
+	case "description":
+		r.Description = value
+	}
+}
+
+// ParseOutput parses the output of a package manager command into package records.
+//
+// Parameters:
+//   - managerName: The name of the package manager, stored in each record.
+//   - spec: The rules used to parse the output.
+//   - output: The standard output of the command.
+//
+// Returns:
+//   - []Record: The records parsed from the output, skipping the ones without a package name,
+//     or no record if the output is blank.
+//   - error: An error if the parse rules are invalid or the output cannot be decoded.
+//
+// Example usage:
+//
+//	records, err := ParseOutput("apt", utils.ParseSpec{Regex: `^(?P<package>\S+) - (?P<description>.*)$`}, output)
+//
+// This function performs the following steps:
+//  1. Skips the number of lines requested by the parse rules, returning no record if the
+//     rest of the output is blank, e.g. a JSON command printing nothing when nothing matches.
+//  2. Parses the remaining output as JSON, key-value lines, columns or with a regular
+//     expression, depending on the parse rules.
+//  3. Drops the records without a package name.
+func ParseOutput(managerName string, spec utils.ParseSpec, output []byte) ([]Record, error) {
+	// Skip the requested number of lines, and return no record for an empty output
+	lines := strings.Split(strings.ReplaceAll(string(output), "\r\n", "\n"), "\n")
+	if spec.Skip >= len(lines) {
+		return nil, nil
+	}
+	lines = lines[spec.Skip:]
+	if strings.TrimSpace(strings.Join(lines, "")) == "" {
+		return nil, nil
+	}
+
+	// Parse the output depending on the parse rules
+	var records []Record
+	var err error
+	switch {
+	case spec.JSON != nil:
+		records, err = parseJSON(*spec.JSON, strings.Join(lines, "\n"))
+	case len(spec.Keys) > 0:
+		records = parseKeys(spec.Keys, spec.Separator, lines)
+	case len(spec.Columns) > 0:
+		records = parseColumns(spec.Columns, spec.Delimiter, lines)
+	case spec.Regex != "":
+		records, err = parseRegex(spec.Regex, lines)
+	default:
+		err = errors.New("parse rule has no regex, columns, keys or json")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse output of %s: %v", managerName, err)
+	}
+
+	// Drop the records without a package name
+	parsed := make([]Record, 0, len(records))
+	for _, record := range records {
+		if record.Package != "" {
+			record.Manager = managerName
+			parsed = append(parsed, record)
+		}
+	}
+	return parsed, nil
+}
+
+// parseRegex parses lines with a regular expression whose named groups are record fields.
+//
+// Parameters:
+//   - expr: The regular expression matched against each line.
+//   - lines: The lines of the output.
+//
+// Returns:
+//   - []Record: One record per matching line.
+//   - error: An error if the regular expression is invalid.
+func parseRegex(expr string, lines []string) ([]Record, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, line := range lines {
+		match := re.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		var record Record
+		for i, name := range re.SubexpNames() {
+			record.set(name, match[i])
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// parseColumns parses lines made of columns.
+//
+// Parameters:
+//   - columns: The names of the columns, where the last one receives the rest of the line.
+//   - delimiter: The delimiter of the columns, or an empty string to split on whitespace.
+//   - lines: The lines of the output.
+//
+// Returns:
+//   - []Record: One record per non-empty line.
+func parseColumns(columns []string, delimiter string, lines []string) []Record {
+	var records []Record
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var record Record
+		for i, value := range splitColumns(line, delimiter, len(columns)) {
+			record.set(columns[i], value)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// splitColumns splits a line into at most n columns, the last one receiving the rest of the line.
+//
+// Parameters:
+//   - line: The line to split.
+//   - delimiter: The delimiter of the columns, or an empty string to split on runs of whitespace.
+//   - n: The maximum number of columns.
+//
+// Returns:
+//   - []string: The columns of the line.
+func splitColumns(line string, delimiter string, n int) []string {
+	if delimiter != "" {
+		return strings.SplitN(line, delimiter, n)
+	}
+
+	var values []string
+	rest := strings.TrimSpace(line)
+	for len(values) < n-1 && rest != "" {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			break
+		}
+		values = append(values, rest[:end])
+		rest = strings.TrimSpace(rest[end:])
+	}
+	return append(values, rest)
+}
+
+// parseKeys parses "Key: value" lines, where blank lines separate the records.
+//
+// Parameters:
+//   - keys: A map where the keys are record fields and the values are the keys of the output.
+//   - separator: The separator between the keys and the values, ":" if it is empty.
+//   - lines: The lines of the output.
+//
+// Returns:
+//   - []Record: One record per block of lines.
+func parseKeys(keys map[string]string, separator string, lines []string) []Record {
+	if separator == "" {
+		separator = ":"
+	}
+
+	// Map the keys of the output to the record fields
+	fields := make(map[string]string, len(keys))
+	for field, key := range keys {
+		fields[strings.ToLower(key)] = field
+	}
+
+	var records []Record
+	var record Record
+	started := false
+	for _, line := range append(lines, "") {
+		// Close the current record on blank lines
+		if strings.TrimSpace(line) == "" {
+			if started {
+				records = append(records, record)
+			}
+			record, started = Record{}, false
+			continue
+		}
+
+		// Set the field of the key, ignoring continuation lines and unknown keys
+		key, value, ok := strings.Cut(line, separator)
+		if !ok || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		if field, ok := fields[strings.ToLower(strings.TrimSpace(key))]; ok {
+			record.set(field, value)
+			started = true
+		}
+	}
+	return records
+}
+
+// parseJSON parses a JSON document.
+//
+// Parameters:
+//   - spec: The rules used to read the records from the document.
+//   - data: The JSON document.
+//
+// Returns:
+//   - []Record: The records read from the value at the path of the rules.
+//   - error: An error if the document is not valid JSON.
+func parseJSON(spec utils.JSONSpec, data string) ([]Record, error) {
+	var document any
+	if err := json.Unmarshal([]byte(data), &document); err != nil {
+		return nil, err
+	}
+
+	// Use the default fields of the records
+	packageField := valueOrDefault(spec.Package, "name")
+	versionField := valueOrDefault(spec.Version, "version")
+	descriptionField := valueOrDefault(spec.Description, "description")
+	newRecord := func(name string, value any) Record {
+		record := Record{}
+		if packageField == jsonKeyField {
+			record.set("package", name)
+		} else {
+			record.set("package", jsonString(jsonLookup(value, packageField)))
+		}
+		record.set("version", jsonString(jsonLookup(value, versionField)))
+		record.set("description", jsonString(jsonLookup(value, descriptionField)))
+		return record
+	}
+
+	// Read the records from the value at the path
+	var records []Record
+	switch value := jsonLookup(document, spec.Path).(type) {
+	case []any:
+		for _, item := range value {
+			records = append(records, newRecord("", item))
+		}
+	case map[string]any:
+		if packageField != jsonKeyField {
+			return []Record{newRecord("", value)}, nil
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			records = append(records, newRecord(name, value[name]))
+		}
+	}
+	return records, nil
+}
+
+// jsonLookup returns the value at a dot-separated path of a JSON value.
+//
+// Parameters:
+//   - value: The decoded JSON value.
+//   - path: The dot-separated keys of the nested objects, or an empty string for the value itself.
+//
+// Returns:
+//   - any: The value at the path, or nil if the path does not exist.
+func jsonLookup(value any, path string) any {
+	if path == "" {
+		return value
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// jsonString formats a decoded JSON value as a string.
+//
+// Parameters:
+//   - value: The decoded JSON value.
+//
+// Returns:
+//   - string: The string itself, an empty string for null, or the JSON encoding of other values.
+func jsonString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// valueOrDefault returns the value, or the default value if it is empty.
+//
+// Parameters:
+//   - value: The value.
+//   - defaultValue: The value returned if value is empty.
+//
+// Returns:
+//   - string: The value or the default value.
+func valueOrDefault(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
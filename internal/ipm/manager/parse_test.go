@@ -0,0 +1,313 @@
+package manager
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"ipm/internal/ipm/utils"
+)
+
+// bundledParseSpec returns the parse rule of a command in a config bundled with ipm, so that
+// the rules shipped to the users are tested against captured output.
+func bundledParseSpec(t *testing.T, managerName string, command string) utils.ParseSpec {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "config", "manager", "config", managerName+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config utils.CommandConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatalf("invalid %s config: %v", managerName, err)
+	}
+	spec, ok := config.Parse[command]
+	if !ok {
+		t.Fatalf("%s has no parse rule for %s", managerName, command)
+	}
+	return spec
+}
+
+// record returns a record of a package manager, for the expected results.
+func record(managerName string, packageName string, version string, description string) Record {
+	return Record{Manager: managerName, Package: packageName, Version: version, Description: description}
+}
+
+func TestParseOutputBundledRules(t *testing.T) {
+	tests := []struct {
+		manager string
+		command string
+		output  string
+		want    []Record
+	}{
+		{
+			"apt", "list",
+			`Desired=Unknown/Install/Remove/Purge/Hold
+| Status=Not/Inst/Conf-files/Unpacked/halF-conf/Half-inst/trig-aWait/Trig-pend
+|/ Err?=(none)/Reinst-required (Status,Err: uppercase=bad)
+||/ Name           Version      Architecture Description
++++-==============-============-============-=================================
+ii  adduser        3.134        all          add and remove users and groups
+ii  libc6:amd64    2.36-9       amd64        GNU C Library: Shared libraries
+rc  oldpkg         1.0          amd64        removed but not purged
+`,
+			[]Record{
+				record("apt", "adduser", "3.134", "add and remove users and groups"),
+				record("apt", "libc6", "2.36-9", "GNU C Library: Shared libraries"),
+			},
+		},
+		{
+			"apt", "search",
+			`jq - lightweight and flexible command-line JSON processor
+libjq1 - lightweight and flexible command-line JSON processor - shared library
+`,
+			[]Record{
+				record("apt", "jq", "", "lightweight and flexible command-line JSON processor"),
+				record("apt", "libjq1", "", "lightweight and flexible command-line JSON processor - shared library"),
+			},
+		},
+		{
+			"apt", "info",
+			`Package: jq
+Version: 1.6-2.1ubuntu3
+Priority: optional
+Description: lightweight and flexible command-line JSON processor
+ jq is like sed for JSON data
+Homepage: https://github.com/stedolan/jq
+
+Package: jq
+Version: 1.6-2.1
+Description: lightweight and flexible command-line JSON processor
+`,
+			[]Record{
+				record("apt", "jq", "1.6-2.1ubuntu3", "lightweight and flexible command-line JSON processor"),
+				record("apt", "jq", "1.6-2.1", "lightweight and flexible command-line JSON processor"),
+			},
+		},
+		{
+			"npm", "list",
+			`{
+  "name": "lib",
+  "dependencies": {
+    "npm": {"version": "10.2.4", "overridden": false},
+    "corepack": {"version": "0.22.0", "overridden": false}
+  }
+}`,
+			[]Record{
+				record("npm", "corepack", "0.22.0", ""),
+				record("npm", "npm", "10.2.4", ""),
+			},
+		},
+		{
+			"npm", "search",
+			`[
+{"name":"left-pad","description":"String left pad","version":"1.3.0","keywords":["leftpad"]},
+{"name":"@types/left-pad","description":null,"version":"1.2.0"}
+]`,
+			[]Record{
+				record("npm", "left-pad", "1.3.0", "String left pad"),
+				record("npm", "@types/left-pad", "1.2.0", ""),
+			},
+		},
+		{
+			"npm", "info",
+			`{"name": "left-pad", "version": "1.3.0", "description": "String left pad", "dist-tags": {"latest": "1.3.0"}}`,
+			[]Record{record("npm", "left-pad", "1.3.0", "String left pad")},
+		},
+		{
+			"pip", "list",
+			`[{"name": "attrs", "version": "22.1.0"}, {"name": "pip", "version": "23.0.1"}]`,
+			[]Record{
+				record("pip", "attrs", "22.1.0", ""),
+				record("pip", "pip", "23.0.1", ""),
+			},
+		},
+		{
+			"pip", "info",
+			`Name: attrs
+Version: 22.1.0
+Summary: Classes Without Boilerplate
+Home-page: https://www.attrs.org/
+Requires:
+`,
+			[]Record{record("pip", "attrs", "22.1.0", "Classes Without Boilerplate")},
+		},
+		{
+			"brew", "list",
+			`jq 1.7.1
+openssl@3 3.2.0 3.1.4
+`,
+			[]Record{
+				record("brew", "jq", "1.7.1", ""),
+				record("brew", "openssl@3", "3.2.0 3.1.4", ""),
+			},
+		},
+		{
+			"brew", "search",
+			`==> Formulae
+jq
+jql
+
+==> Casks
+jqbx
+`,
+			[]Record{
+				record("brew", "jq", "", ""),
+				record("brew", "jql", "", ""),
+				record("brew", "jqbx", "", ""),
+			},
+		},
+		{
+			"brew", "info",
+			`{"formulae": [{"name": "jq", "desc": "Lightweight and flexible command-line JSON processor", "versions": {"stable": "1.7.1", "head": "HEAD", "bottle": true}}], "casks": []}`,
+			[]Record{record("brew", "jq", "1.7.1", "Lightweight and flexible command-line JSON processor")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.manager+" "+tt.command, func(t *testing.T) {
+			got, err := ParseOutput(tt.manager, bundledParseSpec(t, tt.manager, tt.command), []byte(tt.output))
+			if err != nil {
+				t.Fatalf("ParseOutput() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseOutput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOutputEmpty(t *testing.T) {
+	specs := map[string]utils.ParseSpec{
+		"regex":   {Regex: `^(?P<package>\S+)$`},
+		"columns": {Columns: []string{"package", "version"}},
+		"keys":    {Keys: map[string]string{"package": "Name"}},
+		"json":    {JSON: &utils.JSONSpec{}},
+		"skip":    {Columns: []string{"package"}, Skip: 5},
+	}
+	for name, spec := range specs {
+		for _, output := range []string{"", "\n", "  \r\n\t\n"} {
+			got, err := ParseOutput("test", spec, []byte(output))
+			if err != nil {
+				t.Errorf("%s: ParseOutput(%q) error = %v", name, output, err)
+			}
+			if len(got) != 0 {
+				t.Errorf("%s: ParseOutput(%q) = %+v, want no record", name, output, got)
+			}
+		}
+	}
+}
+
+func TestParseOutputColumns(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   utils.ParseSpec
+		output string
+		want   []Record
+	}{
+		{
+			"missing columns are left empty",
+			utils.ParseSpec{Columns: []string{"package", "version", "description"}},
+			"jq\nfd 8.7.0\n",
+			[]Record{record("test", "jq", "", ""), record("test", "fd", "8.7.0", "")},
+		},
+		{
+			"extra columns go to the last column",
+			utils.ParseSpec{Columns: []string{"package", "version"}},
+			"jq   1.7.1   extra   words\n",
+			[]Record{record("test", "jq", "1.7.1   extra   words", "")},
+		},
+		{
+			"delimiter not found",
+			utils.ParseSpec{Columns: []string{"package", "version"}, Delimiter: "|"},
+			"jq 1.7.1\nfd|8.7.0\n",
+			[]Record{record("test", "jq 1.7.1", "", ""), record("test", "fd", "8.7.0", "")},
+		},
+		{
+			"empty package column dropped",
+			utils.ParseSpec{Columns: []string{"package", "version"}, Delimiter: ","},
+			",1.0\njq,1.7.1\n",
+			[]Record{record("test", "jq", "1.7.1", "")},
+		},
+		{
+			"ignored column",
+			utils.ParseSpec{Columns: []string{"status", "package", "version"}, Skip: 2},
+			"Status Name Version\n------ ---- -------\nok jq 1.7.1\n",
+			[]Record{record("test", "jq", "1.7.1", "")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOutput("test", tt.spec, []byte(tt.output))
+			if err != nil {
+				t.Fatalf("ParseOutput() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseOutput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOutputErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   utils.ParseSpec
+		output string
+	}{
+		{"malformed json", utils.ParseSpec{JSON: &utils.JSONSpec{}}, `[{"name": "jq",`},
+		{"text instead of json", utils.ParseSpec{JSON: &utils.JSONSpec{}}, "npm ERR! code E404\n"},
+		{"invalid regex", utils.ParseSpec{Regex: `(?P<package>`}, "jq\n"},
+		{"no rule", utils.ParseSpec{}, "jq\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOutput("test", tt.spec, []byte(tt.output))
+			if err == nil {
+				t.Fatalf("ParseOutput() = %+v, want an error", got)
+			}
+			if !strings.Contains(err.Error(), "failed to parse output of test") {
+				t.Errorf("ParseOutput() error = %v, want the package manager in the message", err)
+			}
+		})
+	}
+}
+
+func TestParseOutputJSONShapes(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   utils.JSONSpec
+		output string
+		want   []Record
+	}{
+		{"missing path", utils.JSONSpec{Path: "dependencies"}, `{"name": "lib"}`, nil},
+		{"scalar", utils.JSONSpec{}, `"jq"`, nil},
+		{"non-string fields", utils.JSONSpec{Version: "version"}, `[{"name": "jq", "version": 2}]`, []Record{record("test", "jq", "2", "")}},
+		{"objects without a name", utils.JSONSpec{}, `[{"version": "1.0"}, {"name": "jq"}]`, []Record{record("test", "jq", "", "")}},
+		{"nested path", utils.JSONSpec{Path: "a.b", Package: "id"}, `{"a": {"b": [{"id": "jq"}]}}`, []Record{record("test", "jq", "", "")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := tt.spec
+			got, err := ParseOutput("test", utils.ParseSpec{JSON: &spec}, []byte(tt.output))
+			if err != nil {
+				t.Fatalf("ParseOutput() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseOutput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOutputRegexNoMatch(t *testing.T) {
+	spec := bundledParseSpec(t, "apt", "search")
+	got, err := ParseOutput("apt", spec, []byte("Sorting... Done\nFull Text Search... Done\n"))
+	if err != nil {
+		t.Fatalf("ParseOutput() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ParseOutput() = %+v, want no record", got)
+	}
+}
@@ -0,0 +1,120 @@
+// Package manager provides utilities for executing command templates and running commands
+package manager
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// posixSafeArgument matches arguments that do not need quoting for a POSIX shell.
+var posixSafeArgument = regexp.MustCompile(`^[A-Za-z0-9@%+=:,./_-]+$`)
+
+// windowsSafeArgument matches arguments that do not need quoting for cmd.exe.
+var windowsSafeArgument = regexp.MustCompile(`^[A-Za-z0-9@+=:,./\\_-]+$`)
+
+// windowsMetaCharacters holds the characters that cmd.exe interprets unless escaped with a caret.
+const windowsMetaCharacters = `()%!^"<>&|`
+
+// quoteArgument quotes a single argument for the shell used by runCommand on the current platform.
+//
+// Parameters:
+//   - arg: The argument to quote.
+//
+// Returns:
+//   - string: The argument quoted for "sh -c" on Unix-like systems or "cmd /S /C" on Windows.
+//
+// Example usage:
+//
+//	quoted := quoteArgument("it's") // 'it'\''s' on Unix-like systems
+func quoteArgument(arg string) string {
+	if runtime.GOOS == "windows" {
+		return quoteWindowsArgument(arg)
+	}
+	return quotePOSIXArgument(arg)
+}
+
+// quotePOSIXArgument quotes an argument for a POSIX shell.
+//
+// Arguments made only of safe characters are returned unchanged. Any other argument
+// is wrapped in single quotes, and each embedded single quote closes the quoting,
+// adds an escaped quote and reopens the quoting.
+//
+// Parameters:
+//   - arg: The argument to quote.
+//
+// Returns:
+//   - string: The quoted argument.
+func quotePOSIXArgument(arg string) string {
+	if posixSafeArgument.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// quoteWindowsArgument quotes an argument for cmd.exe.
+//
+// Arguments made only of safe characters are returned unchanged. Any other argument
+// is first quoted following the CommandLineToArgvW rules used by most Windows programs,
+// then every cmd.exe meta character is escaped with a caret so that cmd.exe passes the
+// quoted argument through verbatim.
+//
+// Parameters:
+//   - arg: The argument to quote.
+//
+// Returns:
+//   - string: The quoted argument.
+func quoteWindowsArgument(arg string) string {
+	if windowsSafeArgument.MatchString(arg) {
+		return arg
+	}
+
+	// Quote the argument following the CommandLineToArgvW rules
+	var argv strings.Builder
+	argv.WriteByte('"')
+	backslashes := 0
+	for i := 0; i < len(arg); i++ {
+		switch arg[i] {
+		case '\\':
+			backslashes++
+		case '"':
+			argv.WriteString(strings.Repeat(`\`, backslashes*2+1))
+			argv.WriteByte('"')
+			backslashes = 0
+		default:
+			argv.WriteString(strings.Repeat(`\`, backslashes))
+			argv.WriteByte(arg[i])
+			backslashes = 0
+		}
+	}
+	argv.WriteString(strings.Repeat(`\`, backslashes*2))
+	argv.WriteByte('"')
+
+	// Escape the cmd.exe meta characters with a caret
+	var escaped strings.Builder
+	for _, r := range argv.String() {
+		if strings.ContainsRune(windowsMetaCharacters, r) {
+			escaped.WriteByte('^')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
+
+// windowsCommandLine returns the command line running a shell command with cmd.exe.
+//
+// With /S, cmd.exe strips the outer quotes and runs the rest of the command line verbatim,
+// so the carets and quotes added by quoteWindowsArgument are interpreted by cmd.exe only once.
+//
+// Parameters:
+//   - shell: The rendered shell command.
+//
+// Returns:
+//   - string: The command line of cmd.exe.
+//
+// Example usage:
+//
+//	cmdLine := windowsCommandLine("choco install -y jq") // cmd /S /C "choco install -y jq"
+func windowsCommandLine(shell string) string {
+	return `cmd /S /C "` + shell + `"`
+}
@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// hostileArguments are package names that must reach the package manager as a single,
+// unchanged argument instead of being interpreted by the shell.
+var hostileArguments = []string{
+	"jq",
+	"foo; rm -rf ~",
+	"it's",
+	`say "hi"`,
+	"$(id)",
+	"`id`",
+	"%PATH%",
+	"&|^<>",
+	"",
+	"-rf",
+	`a b\`,
+	`\"`,
+	"a\tb",
+}
+
+func TestQuotePOSIXArgument(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"jq", "jq"},
+		{"foo; rm -rf ~", "'foo; rm -rf ~'"},
+		{"it's", `'it'\''s'`},
+		{`say "hi"`, `'say "hi"'`},
+		{"$(id)", "'$(id)'"},
+		{"`id`", "'`id`'"},
+		{"%PATH%", "%PATH%"},
+		{"&|^<>", "'&|^<>'"},
+		{"", "''"},
+		{"-rf", "-rf"},
+	}
+	for _, tt := range tests {
+		if got := quotePOSIXArgument(tt.arg); got != tt.want {
+			t.Errorf("quotePOSIXArgument(%q) = %q, want %q", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestQuotePOSIXArgumentRoundTrip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	for _, arg := range hostileArguments {
+		out, err := exec.Command("sh", "-c", "printf '%s' "+quotePOSIXArgument(arg)).Output()
+		if err != nil {
+			t.Fatalf("sh -c failed for %q: %v", arg, err)
+		}
+		if string(out) != arg {
+			t.Errorf("sh received %q, want %q", out, arg)
+		}
+	}
+}
+
+func TestQuoteWindowsArgument(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"jq", "jq"},
+		{`C:\tools\jq`, `C:\tools\jq`},
+		{"foo; rm -rf ~", `^"foo; rm -rf ~^"`},
+		{"it's", `^"it's^"`},
+		{`say "hi"`, `^"say \^"hi\^"^"`},
+		{"$(id)", `^"$^(id^)^"`},
+		{"`id`", "^\"`id`^\""},
+		{"%PATH%", `^"^%PATH^%^"`},
+		{"&|^<>", `^"^&^|^^^<^>^"`},
+		{"", `^"^"`},
+		{"-rf", "-rf"},
+		{`a b\`, `^"a b\\^"`},
+	}
+	for _, tt := range tests {
+		if got := quoteWindowsArgument(tt.arg); got != tt.want {
+			t.Errorf("quoteWindowsArgument(%q) = %q, want %q", tt.arg, got, tt.want)
+		}
+	}
+}
+
+// TestQuoteWindowsArgumentRoundTrip checks that cmd.exe and then CommandLineToArgvW turn
+// each quoted argument back into the original argument, by simulating both steps.
+func TestQuoteWindowsArgumentRoundTrip(t *testing.T) {
+	for _, arg := range hostileArguments {
+		cmdLine := windowsCommandLine("choco install " + quoteWindowsArgument(arg))
+		args := splitWindowsCommandLine(removeCmdCarets(stripCmdOuterQuotes(t, cmdLine)))
+		want := []string{"choco", "install", arg}
+		if strings.Join(args, "\x00") != strings.Join(want, "\x00") {
+			t.Errorf("%q was received as %q, want %q", arg, args, want)
+		}
+	}
+}
+
+// stripCmdOuterQuotes returns the command run by "cmd /S /C", without the outer quotes.
+func stripCmdOuterQuotes(t *testing.T, cmdLine string) string {
+	t.Helper()
+	command, ok := strings.CutPrefix(cmdLine, `cmd /S /C "`)
+	if !ok || !strings.HasSuffix(command, `"`) {
+		t.Fatalf("unexpected command line %q", cmdLine)
+	}
+	return strings.TrimSuffix(command, `"`)
+}
+
+// removeCmdCarets removes the escaping carets as cmd.exe does outside quotes. Since every
+// quote is escaped by quoteWindowsArgument, cmd.exe never enters a quoted section.
+func removeCmdCarets(command string) string {
+	var builder strings.Builder
+	for i := 0; i < len(command); i++ {
+		if command[i] == '^' && i+1 < len(command) {
+			i++
+		}
+		builder.WriteByte(command[i])
+	}
+	return builder.String()
+}
+
+// splitWindowsCommandLine splits a command line following the CommandLineToArgvW rules.
+func splitWindowsCommandLine(cmdLine string) []string {
+	var args []string
+	var arg strings.Builder
+	inQuotes, hasArg := false, false
+	for i := 0; i < len(cmdLine); i++ {
+		switch c := cmdLine[i]; {
+		case c == '\\':
+			backslashes := 0
+			for i < len(cmdLine) && cmdLine[i] == '\\' {
+				backslashes++
+				i++
+			}
+			if i < len(cmdLine) && cmdLine[i] == '"' {
+				arg.WriteString(strings.Repeat(`\`, backslashes/2))
+				if backslashes%2 == 1 {
+					arg.WriteByte('"')
+				} else {
+					inQuotes = !inQuotes
+				}
+			} else {
+				arg.WriteString(strings.Repeat(`\`, backslashes))
+				i--
+			}
+			hasArg = true
+		case c == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case (c == ' ' || c == '\t') && !inQuotes:
+			if hasArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				hasArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+// Package manager provides utilities for executing command templates and running commands
+package manager
+
+import "os/exec"
+
+// shellCommand creates the command running a shell command with the POSIX shell.
+//
+// Parameters:
+//   - shell: The rendered shell command.
+//
+// Returns:
+//   - *exec.Cmd: The command running "sh -c" with the shell command.
+func shellCommand(shell string) *exec.Cmd {
+	return exec.Command("sh", "-c", shell)
+}
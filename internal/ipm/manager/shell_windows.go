@@ -0,0 +1,25 @@
+//go:build windows
+
+// Package manager provides utilities for executing command templates and running commands
+package manager
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand creates the command running a shell command with cmd.exe.
+//
+// The command line is passed to cmd.exe as is, instead of being escaped again by
+// exec.Command, so that the quoting of quoteWindowsArgument reaches cmd.exe unchanged.
+//
+// Parameters:
+//   - shell: The rendered shell command.
+//
+// Returns:
+//   - *exec.Cmd: The command running "cmd /S /C" with the shell command.
+func shellCommand(shell string) *exec.Cmd {
+	cmd := exec.Command("cmd")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: windowsCommandLine(shell)}
+	return cmd
+}
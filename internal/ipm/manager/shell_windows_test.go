@@ -0,0 +1,42 @@
+//go:build windows
+
+package manager
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// echoArgsEnv makes the test binary print its arguments instead of running the tests.
+const echoArgsEnv = "IPM_TEST_ECHO_ARGS"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(echoArgsEnv) == "1" {
+		for i, arg := range os.Args {
+			if arg == "--" {
+				fmt.Print(strings.Join(os.Args[i+1:], "\x00"))
+				break
+			}
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// TestShellCommandRoundTrip runs the test binary through cmd.exe with each hostile
+// argument, and checks that it receives the argument unchanged.
+func TestShellCommandRoundTrip(t *testing.T) {
+	for _, arg := range hostileArguments {
+		cmd := shellCommand(quoteWindowsArgument(os.Args[0]) + " -- " + quoteWindowsArgument(arg))
+		cmd.Env = append(os.Environ(), echoArgsEnv+"=1")
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("cmd.exe failed for %q: %v", arg, err)
+		}
+		if string(out) != arg {
+			t.Errorf("cmd.exe passed %q, want %q", out, arg)
+		}
+	}
+}
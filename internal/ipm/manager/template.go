@@ -0,0 +1,163 @@
+// Package manager provides utilities for executing command templates and running commands
+package manager
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// argvSeparator separates the values of a list rendered inside an argument template,
+// so that the rendered argument can be split back into one argument per value.
+const argvSeparator = "\x00"
+
+// paramFormat describes how user-supplied values are rendered in a command template.
+//
+// Fields:
+//   - quote: Quotes a single value.
+//   - sep: Separates the values of a list.
+type paramFormat struct {
+	quote func(string) string
+	sep   string
+}
+
+// shellFormat renders user-supplied values quoted for the shell used by runCommand.
+var shellFormat = &paramFormat{quote: quoteArgument, sep: " "}
+
+// argvFormat renders user-supplied values verbatim for commands executed without a shell.
+var argvFormat = &paramFormat{quote: func(value string) string { return value }, sep: argvSeparator}
+
+// templateParam represents a single value supplied by the user to a command template.
+//
+// When rendered in a shell command, the value is quoted for the shell used by runCommand
+// on the current platform, so that hostile input such as "foo; rm -rf ~" is passed to the
+// package manager as a single argument instead of being executed. Use the raw template
+// function to opt out of the quoting. When rendered in an argument list, the value is
+// passed verbatim since no shell is involved.
+type templateParam struct {
+	value  string
+	format *paramFormat
+}
+
+// String returns the value formatted for the command being rendered.
+func (p templateParam) String() string {
+	return p.format.quote(p.value)
+}
+
+// templateParams represents a list of values supplied by the user to a command template.
+//
+// When rendered in a shell command, every value is quoted for the shell and the quoted
+// values are joined with spaces. When rendered in an argument list, every value becomes
+// a separate argument.
+type templateParams []templateParam
+
+// String returns the values formatted for the command being rendered.
+func (p templateParams) String() string {
+	if len(p) == 0 {
+		return ""
+	}
+	return joinParams(p, p[0].format.sep)
+}
+
+// templateData represents the values available to a command template.
+//
+// Fields:
+//   - Package: The package passed on the command line. When several packages are
+//     rendered in a single invocation, they are joined with spaces.
+//   - Packages: All the packages passed on the command line for the invocation.
+//   - Version: The version the package is pinned to, e.g. by the install-version command,
+//     or an empty string.
+//
+// All fields are quoted for the shell when rendered in a shell command.
+//
+// Example templates:
+//
+//	apt-get install -y {{.Package}}
+//	brew install {{join .Packages " "}}
+//	winget install --id {{raw .Package}}
+//	npm install -g {{.Package}}@{{.Version}}
+type templateData struct {
+	Package  templateParams
+	Packages templateParams
+	Version  templateParam
+}
+
+// templateFuncs holds the helper functions available to command templates.
+//
+// Functions:
+//   - join: Joins a list of user-supplied values with the given separator, quoting each value.
+//   - quote: Quotes a string for the shell used by runCommand.
+//   - raw: Returns user-supplied values without quoting, for configs that genuinely need raw text.
+var templateFuncs = template.FuncMap{
+	"join":  joinParams,
+	"quote": quoteArgument,
+	"raw":   rawParams,
+}
+
+// newTemplateData creates the template data for the given parameters.
+//
+// Parameters:
+//   - params: A slice of strings containing the parameters passed on the command line.
+//   - version: The version the package is pinned to, or an empty string.
+//   - format: The format used to render the parameters, shellFormat or argvFormat.
+//
+// Returns:
+//   - templateData: The template data exposing the parameters as Package and Packages,
+//     and the version as Version.
+//
+// Example usage:
+//
+//	data := newTemplateData([]string{"jq", "git"}, "", shellFormat) // Package: "jq git", Packages: ["jq", "git"]
+func newTemplateData(params []string, version string, format *paramFormat) templateData {
+	values := make(templateParams, 0, len(params))
+	for _, param := range params {
+		values = append(values, templateParam{value: param, format: format})
+	}
+
+	return templateData{
+		Package:  values,
+		Packages: values,
+		Version:  templateParam{value: version, format: format},
+	}
+}
+
+// joinParams formats every user-supplied value and joins them with the given separator.
+//
+// Parameters:
+//   - params: The user-supplied values to join.
+//   - sep: The separator placed between the formatted values.
+//
+// Returns:
+//   - string: The formatted values joined with the separator.
+func joinParams(params templateParams, sep string) string {
+	quoted := make([]string, 0, len(params))
+	for _, param := range params {
+		quoted = append(quoted, param.String())
+	}
+	return strings.Join(quoted, sep)
+}
+
+// rawParams returns user-supplied values without quoting them for the shell.
+//
+// Parameters:
+//   - value: A single value, a list of values, or any other value.
+//
+// Returns:
+//   - string: The unquoted text of the value.
+func rawParams(value any) string {
+	switch v := value.(type) {
+	case templateParam:
+		return v.value
+	case templateParams:
+		if len(v) == 0 {
+			return ""
+		}
+		raw := make([]string, 0, len(v))
+		for _, param := range v {
+			raw = append(raw, param.value)
+		}
+		return strings.Join(raw, v[0].format.sep)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,73 @@
+package manager
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestParseCommandTemplateShellFormat(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("expects the quoting of a POSIX shell")
+	}
+	tests := []struct {
+		name     string
+		template string
+		params   []string
+		version  string
+		want     string
+	}{
+		{"command separator", "apt-get install -y {{.Package}}", []string{"foo; rm -rf ~"}, "", "apt-get install -y 'foo; rm -rf ~'"},
+		{"several packages", "apt-get install -y {{.Package}}", []string{"jq", "$(id)"}, "", "apt-get install -y jq '$(id)'"},
+		{"packages", "brew install {{.Packages}}", []string{"`id`", `say "hi"`}, "", "brew install '`id`' 'say \"hi\"'"},
+		{"join", `pkg add {{join .Packages ","}}`, []string{"a b", "c"}, "", "pkg add 'a b',c"},
+		{"single quote", "pip install {{.Package}}", []string{"it's"}, "", `pip install 'it'\''s'`},
+		{"version", "npm install -g {{.Package}}@{{.Version}}", []string{"left-pad"}, "1.0; id", "npm install -g left-pad@'1.0; id'"},
+		{"leading dash", "apt-get install -y {{.Package}}", []string{"-rf"}, "", "apt-get install -y -rf"},
+		{"empty package", "apt-get install -y {{.Package}}", []string{""}, "", "apt-get install -y ''"},
+		{"no package", "apt-get update", nil, "", "apt-get update"},
+		{"quote", `echo {{quote "it's"}}`, nil, "", `echo 'it'\''s'`},
+		{"raw", "winget install {{raw .Package}}", []string{"a & b"}, "", "winget install a & b"},
+		{"raw packages", "winget install {{raw .Packages}}", []string{"a", "$(id)"}, "", "winget install a $(id)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCommandTemplate(tt.template, tt.params, tt.version, shellFormat)
+			if err != nil {
+				t.Fatalf("parseCommandTemplate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseCommandTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCommandTemplateArgvFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		params   []string
+		version  string
+		want     string
+	}{
+		{"command separator", "{{.Package}}", []string{"foo; rm -rf ~"}, "", "foo; rm -rf ~"},
+		{"quotes", "{{.Package}}", []string{`it's "hi"`}, "", `it's "hi"`},
+		{"substitutions", "{{.Package}}", []string{"$(id)`id`%PATH%"}, "", "$(id)`id`%PATH%"},
+		{"meta characters", "{{.Package}}", []string{"&|^<>"}, "", "&|^<>"},
+		{"several packages", "{{.Packages}}", []string{"jq", "-rf"}, "", "jq" + argvSeparator + "-rf"},
+		{"empty package", "{{.Package}}", []string{""}, "", ""},
+		{"version", "{{.Package}}={{.Version}}", []string{"jq"}, "1.6; id", "jq=1.6; id"},
+		{"raw", "{{raw .Packages}}", []string{"a", "b c"}, "", "a" + argvSeparator + "b c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCommandTemplate(tt.template, tt.params, tt.version, argvFormat)
+			if err != nil {
+				t.Fatalf("parseCommandTemplate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseCommandTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
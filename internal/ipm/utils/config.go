@@ -1,31 +1,407 @@
 // Package utils provides utility functions for the application
 package utils
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
 // CommandConfig represents the structure of the commands in the JSON file.
 //
 // The CommandConfig struct is used to parse and store the configuration of
 // commands from a JSON file. It includes fields for enabling/disabling the
-// config and a map of command names to their respective command strings.
+// config, choosing how multiple packages are passed to the commands, a map
+// of command names to their respective command strings and the fallbacks of
+// the commands that are not available.
 //
 // Fields:
 //   - Enabled: A boolean indicating whether the config are enabled or not.
+//   - Batch: A boolean indicating whether multiple packages are passed to a
+//     single invocation of a command (true) or to one invocation per package
+//     (false).
 //   - Commands: A map where the keys are command names and the values are
-//     the corresponding command strings.
+//     the corresponding commands.
+//   - Fallbacks: A map where the keys are command names and the values are
+//     the behavior when the command is not available, FallbackError (the
+//     default) or FallbackWarn.
+//   - Detect: The rules used to detect the package manager on the system, or
+//     nil if the package manager is never detected.
+//   - Lock: The name of the lock taken by the package manager, e.g. "dpkg" for
+//     apt and nala. Package managers sharing a lock never run concurrently.
+//   - Parse: A map where the keys are command names and the values are the
+//     rules used to parse the output of the commands into package records,
+//     used by "--output" and "search --everywhere".
+//   - ForbidRoot: A boolean indicating whether the package manager refuses to run
+//     as root, e.g. for Homebrew.
 //
 // Example JSON structure:
 //
 //	{
 //	  "enabled": true,
+//	  "batch": true,
 //	  "commands": {
-//	    "install": "install-command",
-//	    "update": "update-command",
+//	    "install": "install-command {{.Package}}",
+//	    "update": ["update-command", "--yes"],
 //	    ...
-//	  }
+//	  },
+//	  "fallbacks": {
+//	    "update": "warn"
+//	  },
+//	  "detect": {
+//	    "os": ["linux"],
+//	    "binary": "install-command",
+//	    "priority": 10
+//	  },
+//	  "lock": "dpkg",
+//	  "parse": {
+//	    "search": {
+//	      "regex": "^(?P<package>\\S+) - (?P<description>.*)$"
+//	    }
+//	  },
+//	  "forbidRoot": false
 //	}
 //
 // This struct is useful for managing the configuration of commands in a
 // structured and easily accessible manner.
 type CommandConfig struct {
-	Enabled  bool              `json:"enabled"`  // Indicates if the config is enabled
-	Commands map[string]string `json:"commands"` // Map of command names to command strings
+	Enabled    bool                 `json:"enabled"`              // Indicates if the config is enabled
+	Batch      bool                 `json:"batch"`                // Indicates if multiple packages share one invocation
+	Commands   map[string]Command   `json:"commands"`             // Map of command names to commands
+	Fallbacks  map[string]string    `json:"fallbacks,omitempty"`  // Map of command names to fallbacks
+	Detect     *DetectConfig        `json:"detect,omitempty"`     // Rules used to detect the package manager
+	Lock       string               `json:"lock,omitempty"`       // Lock shared with other package managers
+	Parse      map[string]ParseSpec `json:"parse,omitempty"`      // Map of command names to parse rules
+	ForbidRoot bool                 `json:"forbidRoot,omitempty"` // Indicates if the package manager refuses to run as root
+}
+
+// ParseSpec represents the rules used to parse the output of a command into package records.
+//
+// The output is parsed in one of the following modes, chosen by the fields that are set:
+//   - Regex: Each line is matched against the regular expression. The named groups
+//     "package", "version" and "description" fill the fields of the record, other
+//     groups are ignored and lines that do not match are skipped.
+//   - Columns: Each non-empty line is split into columns, on Delimiter or on whitespace
+//     when it is empty. Columns are named "package", "version" or "description", or ""
+//     to ignore them, and the last column receives the rest of the line.
+//   - Keys: The output is made of "Key<Separator> value" lines, such as the output of
+//     "apt-cache show", and blank lines separate the records. Keys maps the record
+//     fields to the keys of the output.
+//   - JSON: The output is a JSON document, such as the output of "npm list --json".
+//
+// Fields:
+//   - Run: An optional command run instead of the command template to get a parseable
+//     output, e.g. with a --json flag.
+//   - Regex: The regular expression matched against each line, in the syntax of Go's regexp package.
+//   - Columns: The names of the columns of each line.
+//   - Delimiter: The delimiter of the columns, or an empty string to split on whitespace.
+//   - Keys: A map where the keys are record fields and the values are the keys of the output.
+//   - Separator: The separator between the keys and the values of the output, ":" by default.
+//   - JSON: The rules used to read the records from a JSON document.
+//   - Skip: The number of lines skipped at the start of the output, e.g. to drop table headers.
+//
+// Example JSON structure:
+//
+//	"search": {
+//	  "regex": "^(?P<package>\\S+)\\s+(?P<version>\\S+)\\s+(?P<description>.*)$",
+//	  "skip": 1
+//	},
+//	"list": {
+//	  "run": ["npm", "list", "-g", "--depth=0", "--json"],
+//	  "json": {"path": "dependencies", "package": "$key"}
+//	}
+type ParseSpec struct {
+	Run       *Command          `json:"run,omitempty"`       // Command run to get a parseable output
+	Regex     string            `json:"regex,omitempty"`     // Regular expression with named groups
+	Columns   []string          `json:"columns,omitempty"`   // Names of the columns
+	Delimiter string            `json:"delimiter,omitempty"` // Delimiter of the columns
+	Keys      map[string]string `json:"keys,omitempty"`      // Map of record fields to output keys
+	Separator string            `json:"separator,omitempty"` // Separator between keys and values
+	JSON      *JSONSpec         `json:"json,omitempty"`      // Rules used to read a JSON document
+	Skip      int               `json:"skip,omitempty"`      // Number of lines skipped at the start
+}
+
+// JSONSpec represents the rules used to read package records from a JSON document.
+//
+// The value found at Path is either an array of objects, each one a record, an object
+// whose entries are records when Package is "$key", or a single object that is a record.
+// Fields are read from the objects with dot-separated paths.
+//
+// Fields:
+//   - Path: The dot-separated path to the records, or an empty string for the whole document.
+//   - Package: The path to the name of the package, "name" by default, or "$key" to use
+//     the keys of the object.
+//   - Version: The path to the version of the package, "version" by default.
+//   - Description: The path to the description of the package, "description" by default.
+//
+// Example JSON structure:
+//
+//	"json": {
+//	  "path": "formulae",
+//	  "version": "versions.stable",
+//	  "description": "desc"
+//	}
+type JSONSpec struct {
+	Path        string `json:"path,omitempty"`        // Path to the records
+	Package     string `json:"package,omitempty"`     // Path to the name of the package
+	Version     string `json:"version,omitempty"`     // Path to the version of the package
+	Description string `json:"description,omitempty"` // Path to the description of the package
+}
+
+// DetectConfig represents the rules used to detect a package manager on the system.
+//
+// A package manager is detected when the current operating system is listed in OS,
+// its binary is found in the system's PATH and its probe command, if any, succeeds.
+// The default package manager is the detected one native to the Linux distribution,
+// or the detected one with the lowest priority otherwise.
+//
+// Fields:
+//   - OS: The operating systems supported by the package manager, as reported by
+//     runtime.GOOS (e.g. "linux", "darwin", "windows"). An empty list matches any
+//     operating system.
+//   - Distros: The Linux distributions using the package manager natively, as identified by
+//     the ID and ID_LIKE variables of /etc/os-release (e.g. "debian", "fedora").
+//   - Binary: The binary looked up in the system's PATH. Defaults to the name of the
+//     package manager.
+//   - Priority: The order in which the package managers are considered for the default
+//     commands, lowest first. Package managers without a priority are never used as the
+//     default package manager.
+//   - Probe: An optional command that must exit successfully for the package manager to
+//     be detected, e.g. to tell apart two package managers sharing the same binary.
+//   - Version: An optional command printing the version of the package manager, whose first
+//     line is shown by "ipm detect".
+//
+// Example JSON structure:
+//
+//	"detect": {
+//	  "os": ["linux"],
+//	  "distros": ["void"],
+//	  "binary": "xbps-install",
+//	  "priority": 120,
+//	  "version": ["xbps-install", "--version"]
+//	}
+type DetectConfig struct {
+	OS       []string `json:"os,omitempty"`       // Supported operating systems
+	Distros  []string `json:"distros,omitempty"`  // Linux distributions using it natively
+	Binary   string   `json:"binary,omitempty"`   // Binary looked up in the PATH
+	Priority int      `json:"priority,omitempty"` // Order of detection, lowest first
+	Probe    *Command `json:"probe,omitempty"`    // Command that must succeed
+	Version  *Command `json:"version,omitempty"`  // Command printing the version
+}
+
+// Fallbacks of the commands that are not available.
+const (
+	// FallbackError fails with a CommandUnavailableError. This is the default.
+	FallbackError = "error"
+	// FallbackWarn prints a warning and does nothing.
+	FallbackWarn = "warn"
+)
+
+// Command represents a single command template in the JSON file.
+//
+// A command is declared in one of the following forms:
+//   - A string, which is rendered and run through the shell ("sh -c" or "cmd /S /C").
+//   - An array of strings, where each element is rendered separately and the
+//     resulting arguments are executed directly without an intermediate shell.
+//   - null, when the package manager does not support the command.
+//   - An object whose "run" field holds the command in one of the forms above, along
+//     with metadata such as a "description" shown in the help of the command, the
+//     number of packages it accepts, whether it requires root, whether it only reads
+//     the packages without changing them, and its aliases.
+//
+// Fields:
+//   - Shell: The command template run through the shell.
+//   - Argv: The argument templates executed directly.
+//   - Description: The description of the command shown in the help, or an empty string.
+//   - Args: The number of packages accepted by the command, one of the Args constants, or
+//     an empty string to infer it from the template.
+//   - RequiresRoot: Whether the command must be run with root privileges.
+//   - ReadOnly: Whether the command only reads the packages, such as info or list, instead
+//     of changing the installed packages.
+//   - Aliases: The alternative names of the command.
+//
+// Example JSON values:
+//
+//	"apt-get install -y {{.Package}}"
+//	["apt-get", "install", "-y", "{{.Package}}"]
+//	null
+//	{"run": "apt-get autoremove -y", "description": "Remove packages that are no longer needed"}
+//	{"run": "apt-get install -y {{.Package}}", "args": "many", "requiresRoot": true, "aliases": ["add"]}
+//	{"run": "apt-cache show {{.Package}}", "readOnly": true}
+type Command struct {
+	Shell        string
+	Argv         []string
+	Description  string
+	Args         string
+	RequiresRoot bool
+	ReadOnly     bool
+	Aliases      []string
+}
+
+// Numbers of packages accepted by a command.
+const (
+	// ArgsNone accepts no package.
+	ArgsNone = "none"
+	// ArgsOne accepts exactly one package.
+	ArgsOne = "one"
+	// ArgsMany accepts one or more packages.
+	ArgsMany = "many"
+	// ArgsOptional accepts any number of packages, including none.
+	ArgsOptional = "optional"
+)
+
+// commandObject represents a command declared as an object in the JSON file.
+//
+// Fields:
+//   - Run: The command template, as a string, an array of strings or null.
+//   - Description: The description of the command shown in the help.
+//   - Args: The number of packages accepted by the command.
+//   - RequiresRoot: Whether the command must be run with root privileges.
+//   - ReadOnly: Whether the command only reads the packages without changing them.
+//   - Aliases: The alternative names of the command.
+type commandObject struct {
+	Run          json.RawMessage `json:"run"`
+	Description  string          `json:"description,omitempty"`
+	Args         string          `json:"args,omitempty"`
+	RequiresRoot bool            `json:"requiresRoot,omitempty"`
+	ReadOnly     bool            `json:"readOnly,omitempty"`
+	Aliases      []string        `json:"aliases,omitempty"`
+}
+
+// IsAvailable reports whether the command is declared in the config.
+//
+// Returns:
+//   - bool: True if the command has a shell template or an argument list, false if it is null.
+func (c Command) IsAvailable() bool {
+	return c.Shell != "" || len(c.Argv) > 0
+}
+
+// ArgsSpec returns the number of packages accepted by the command.
+//
+// Returns:
+//   - string: The args declared in the config, or otherwise ArgsMany if the template
+//     references the packages and ArgsNone if it does not.
+//
+// Example usage:
+//
+//	spec := config.Commands["install"].ArgsSpec() // "many"
+func (c Command) ArgsSpec() string {
+	if c.Args != "" {
+		return c.Args
+	}
+	if strings.Contains(c.Shell, ".Package") || strings.Contains(strings.Join(c.Argv, " "), ".Package") {
+		return ArgsMany
+	}
+	return ArgsNone
+}
+
+// CheckArgs checks the number of packages passed to the command.
+//
+// Parameters:
+//   - args: The packages passed to the command.
+//
+// Returns:
+//   - error: An error if the number of packages does not match the args of the command.
+//
+// Example usage:
+//
+//	err := config.Commands["install"].CheckArgs(nil) // "requires at least one package"
+func (c Command) CheckArgs(args []string) error {
+	switch c.ArgsSpec() {
+	case ArgsNone:
+		if len(args) > 0 {
+			return fmt.Errorf("accepts no package, received %d", len(args))
+		}
+	case ArgsOne:
+		if len(args) != 1 {
+			return fmt.Errorf("requires exactly one package, received %d", len(args))
+		}
+	case ArgsMany:
+		if len(args) == 0 {
+			return errors.New("requires at least one package")
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a command declared as a string, an array of strings, null or an object.
+//
+// Parameters:
+//   - data: The JSON value of the command.
+//
+// Returns:
+//   - error: An error if the value is neither a string, an array of strings, null nor an
+//     object whose run field is one of them.
+func (c *Command) UnmarshalJSON(data []byte) error {
+	*c = Command{}
+
+	// Leave the command unavailable when declared as null
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	// Decode the command declared as an object and its run field
+	if bytes.HasPrefix(data, []byte("{")) {
+		var object commandObject
+		if err := json.Unmarshal(data, &object); err != nil {
+			return fmt.Errorf("command must be a string, an array of strings, null or an object: %v", err)
+		}
+		if len(object.Run) == 0 {
+			object.Run = []byte("null")
+		}
+		if err := c.UnmarshalJSON(object.Run); err != nil {
+			return err
+		}
+		c.Description = object.Description
+		c.Args = object.Args
+		c.RequiresRoot = object.RequiresRoot
+		c.ReadOnly = object.ReadOnly
+		c.Aliases = object.Aliases
+		return nil
+	}
+
+	// Decode the command as a shell template
+	if err := json.Unmarshal(data, &c.Shell); err == nil {
+		return nil
+	}
+
+	// Decode the command as an argument list
+	if err := json.Unmarshal(data, &c.Argv); err != nil {
+		return fmt.Errorf("command must be a string, an array of strings, null or an object")
+	}
+	return nil
+}
+
+// MarshalJSON encodes the command in the form it was declared in.
+//
+// Returns:
+//   - []byte: An object holding the command and its metadata if it has any, or otherwise
+//     the JSON array for argument lists, the JSON string for shell templates or null for
+//     unavailable commands.
+//   - error: An error if the command cannot be encoded.
+func (c Command) MarshalJSON() ([]byte, error) {
+	if c.Description != "" || c.Args != "" || c.RequiresRoot || c.ReadOnly || len(c.Aliases) > 0 {
+		run, err := Command{Shell: c.Shell, Argv: c.Argv}.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(commandObject{
+			Run:          run,
+			Description:  c.Description,
+			Args:         c.Args,
+			RequiresRoot: c.RequiresRoot,
+			ReadOnly:     c.ReadOnly,
+			Aliases:      c.Aliases,
+		})
+	}
+	if len(c.Argv) > 0 {
+		return json.Marshal(c.Argv)
+	}
+	if c.Shell != "" {
+		return json.Marshal(c.Shell)
+	}
+	return []byte("null"), nil
 }
@@ -2,157 +2,258 @@
 package utils
 
 import (
+	"fmt"
 	"os/exec"
 	"runtime"
+	"sort"
+	"strings"
 )
 
-// DetectDefaultPackageManager detects the default package manager based on the operating system.
+// Detection represents the package manager detected for the default commands.
+//
+// Fields:
+//   - ManagerName: The name of the detected package manager, or an empty string if no
+//     package manager is detected.
+//   - Reason: A human-readable explanation of why the package manager was chosen.
+type Detection struct {
+	ManagerName string
+	Reason      string
+}
+
+// DetectDefaultPackageManager detects the default package manager from the detection rules of the configs.
+//
+// Parameters:
+//   - configs: A map where the keys are package manager names and the values are their configs.
 //
 // Returns:
-//   - string: The name of the detected package manager, or an empty string if no package manager is detected.
+//   - Detection: The detected package manager and the reason it was chosen.
 //
 // Example usage:
 //
-//	packageManager := DetectDefaultPackageManager()
+//	detection := DetectDefaultPackageManager(configs)
 //
 // This function performs the following steps:
-//  1. Checks the operating system using runtime.GOOS.
-//  2. Calls the appropriate detection function based on the operating system.
-//  3. Returns the name of the detected package manager, or an empty string if no package manager is detected.
-func DetectDefaultPackageManager() string {
-	switch runtime.GOOS {
-	case "windows":
-		return detectWindowsPackageManager()
-	case "darwin":
-		return detectMacOSPackageManager()
-	case "linux":
-		return detectLinuxPackageManager()
-	}
-	return ""
+//  1. Keeps the package managers with a priority that support the operating system reported by runtime.GOOS.
+//  2. Sorts them by priority, lowest first, and then by name.
+//  3. Keeps the package managers whose binary is available and whose probe command succeeds.
+//  4. On Linux, returns the first one that is native to the distribution identified by
+//     /etc/os-release, trying its ID first and then its ID_LIKE identifiers.
+//  5. Otherwise, returns the first one by priority, or no package manager if none is detected.
+func DetectDefaultPackageManager(configs map[string]CommandConfig) Detection {
+	return detectDefaultPackageManager(configs, runtime.GOOS, ReadOSRelease)
 }
 
-// detectWindowsPackageManager checks for the presence of winget, choco, and scoop on Windows.
+// detectDefaultPackageManager detects the default package manager for an operating system.
+//
+// Parameters:
+//   - configs: A map where the keys are package manager names and the values are their configs.
+//   - goos: The operating system, as reported by runtime.GOOS.
+//   - readOSRelease: The function reading the os-release file of the Linux distribution.
 //
 // Returns:
-//   - string: The name of the detected package manager, or an empty string if no package manager is detected.
+//   - Detection: The detected package manager and the reason it was chosen.
 //
 // Example usage:
 //
-//	packageManager := detectWindowsPackageManager()
-//
-// This function performs the following steps:
-//  1. Checks if the "winget" command is available.
-//  2. If "winget" is not available, checks if the "choco" command is available.
-//  3. If "choco" is not available, checks if the "scoop" command is available.
-//  4. Returns the name of the detected package manager, or an empty string if no package manager is detected.
-func detectWindowsPackageManager() string {
-	if isCommandAvailable("winget") {
-		return "winget"
+//	detection := detectDefaultPackageManager(configs, "linux", ReadOSRelease)
+func detectDefaultPackageManager(configs map[string]CommandConfig, goos string, readOSRelease func() (OSRelease, error)) Detection {
+	// Keep the package managers that can be the default on this operating system
+	var candidates []string
+	for managerName, config := range configs {
+		if config.Detect != nil && config.Detect.Priority > 0 && config.Detect.SupportsOS(goos) {
+			candidates = append(candidates, managerName)
+		}
 	}
-	if isCommandAvailable("choco") {
-		return "choco"
+
+	// Sort the package managers by priority and then by name
+	sort.Slice(candidates, func(i, j int) bool {
+		left, right := configs[candidates[i]].Detect, configs[candidates[j]].Detect
+		if left.Priority != right.Priority {
+			return left.Priority < right.Priority
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	// Keep the package managers detected on the system
+	var detected []string
+	for _, managerName := range candidates {
+		if configs[managerName].Detect.IsDetected(managerName) {
+			detected = append(detected, managerName)
+		}
 	}
-	if isCommandAvailable("scoop") {
-		return "scoop"
+	if len(detected) == 0 {
+		return Detection{Reason: "no package manager detected on " + goos}
+	}
+
+	// Prefer the native package manager of the Linux distribution
+	if goos == "linux" {
+		if release, err := readOSRelease(); err == nil {
+			for i, distro := range release.IDs() {
+				for _, managerName := range detected {
+					if !configs[managerName].Detect.SupportsDistro(distro) {
+						continue
+					}
+					variable := "ID"
+					if i > 0 || release.ID == "" {
+						variable = "ID_LIKE"
+					}
+					return Detection{
+						ManagerName: managerName,
+						Reason:      fmt.Sprintf("native package manager of %s (%s in os-release)", distro, variable),
+					}
+				}
+			}
+		}
+	}
+
+	// Fall back to the first package manager by priority
+	managerName := detected[0]
+	return Detection{
+		ManagerName: managerName,
+		Reason:      fmt.Sprintf("first package manager found by priority (%d)", configs[managerName].Detect.Priority),
 	}
-	return ""
 }
 
-// detectMacOSPackageManager checks for the presence of brew and macports on macOS.
+// SupportsOS reports whether the package manager supports the operating system.
 //
-// Returns:
-//   - string: The name of the detected package manager, or an empty string if no package manager is detected.
+// Parameters:
+//   - goos: The operating system, as reported by runtime.GOOS.
 //
-// Example usage:
+// Returns:
+//   - bool: True if the operating system is listed or no operating system is listed, false otherwise.
+func (d DetectConfig) SupportsOS(goos string) bool {
+	if len(d.OS) == 0 {
+		return true
+	}
+	for _, supported := range d.OS {
+		if supported == goos {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsDistro reports whether the package manager is native to the Linux distribution.
 //
-//	packageManager := detectMacOSPackageManager()
+// Parameters:
+//   - distro: The identifier of the distribution, as found in the ID or ID_LIKE variables of os-release.
 //
-// This function performs the following steps:
-//  1. Checks if the "brew" command is available.
-//  2. If "brew" is not available, checks if the "port" command is available.
-//  3. Returns the name of the detected package manager, or an empty string if no package manager is detected.
-func detectMacOSPackageManager() string {
-	if isCommandAvailable("brew") {
-		return "brew"
+// Returns:
+//   - bool: True if the distribution is listed, false otherwise.
+func (d DetectConfig) SupportsDistro(distro string) bool {
+	for _, supported := range d.Distros {
+		if supported == distro {
+			return true
+		}
 	}
-	if isCommandAvailable("port") {
-		return "port"
+	return false
+}
+
+// BinaryName returns the binary looked up in the system's PATH to detect the package manager.
+//
+// Parameters:
+//   - managerName: The name of the package manager, used when no binary is configured.
+//
+// Returns:
+//   - string: The configured binary, or the name of the package manager.
+func (d DetectConfig) BinaryName(managerName string) string {
+	if d.Binary == "" {
+		return managerName
 	}
-	return ""
+	return d.Binary
 }
 
-// detectLinuxPackageManager checks for the presence of various package managers on Linux.
+// IsDetected reports whether the package manager is installed on the system.
+//
+// Parameters:
+//   - managerName: The name of the package manager, used when no binary is configured.
 //
 // Returns:
-//   - string: The name of the detected package manager, or an empty string if no package manager is detected.
+//   - bool: True if the binary is available and the probe command, if any, succeeds.
 //
 // Example usage:
 //
-//	packageManager := detectLinuxPackageManager()
-//
-// This function performs the following steps:
-//  1. Checks if the "apk" command is available (for Alpine Linux).
-//  2. If "apk" is not available, checks if the "apt" command is available (for Debian-based distributions) and so on.
-//  3. Returns the name of the detected package manager, or an empty string if no package manager is detected.
-func detectLinuxPackageManager() string {
-	if isCommandAvailable("apk") { // apk: Alpine Linux
-		return "apk"
-	}
-	if isCommandAvailable("apt") { // apt: Debian-based distributions
-		return "apt"
+//	detected := config.Detect.IsDetected("xbps")
+func (d DetectConfig) IsDetected(managerName string) bool {
+	if !isCommandAvailable(d.BinaryName(managerName)) {
+		return false
 	}
-	if isCommandAvailable("cards") { // cards: NuTyX
-		return "cards"
-	}
-	if isCommandAvailable("dnf") { // dnf: Fedora
-		return "dnf"
-	}
-	if isCommandAvailable("emerge") { // emerge: Gentoo
-		return "emerge"
-	}
-	if isCommandAvailable("eopkg") { // eopkg: Solus
-		return "eopkg"
-	}
-	if isCommandAvailable("guix") { // guix: GNU Guix
-		return "guix"
-	}
-	if isCommandAvailable("nix-env") { // nix-env: Nix
-		return "nix-env"
-	}
-	if isCommandAvailable("opkg") { // opkg: OpenWrt
-		return "opkg"
-	}
-	if isCommandAvailable("pacman") { // pacman: Arch Linux
-		return "pacman"
-	}
-	if isCommandAvailable("slackpkg") { // slackpkg: Slackware
-		return "slackpkg"
-	}
-	if isCommandAvailable("xbps-install") { // xbps-install: Void Linux
-		return "xbps"
-	}
-	if isCommandAvailable("yum") { // yum: CentOS/RHEL
-		return "yum"
+	return d.Probe == nil || runProbe(*d.Probe)
+}
+
+// BinaryPath returns the path to the binary of the package manager.
+//
+// Parameters:
+//   - managerName: The name of the package manager, used when no binary is configured.
+//
+// Returns:
+//   - string: The path to the binary found in the system's PATH, or an empty string if it is not found.
+//
+// Example usage:
+//
+//	path := config.Detect.BinaryPath("apt") // "/usr/bin/apt"
+func (d DetectConfig) BinaryPath(managerName string) string {
+	path, err := exec.LookPath(d.BinaryName(managerName))
+	if err != nil {
+		return ""
 	}
-	if isCommandAvailable("zypper") { // zypper: openSUSE
-		return "zypper"
+	return path
+}
+
+// DetectVersion runs the version command of the package manager.
+//
+// Returns:
+//   - string: The first non-empty line printed by the version command, or an empty string
+//     if no version command is configured or it fails.
+//
+// Example usage:
+//
+//	version := config.Detect.DetectVersion() // "apt 2.6.1 (amd64)"
+func (d DetectConfig) DetectVersion() string {
+	if d.Version == nil {
+		return ""
 	}
 
-	// Additional checks if none of the above tools are available
-	if isCommandAvailable("brew") { // brew: Homebrew on Linux
-		return "brew"
+	// Run the version command and keep the first non-empty line of its output
+	output, err := probeCommand(*d.Version).CombinedOutput()
+	if err != nil {
+		return ""
 	}
-	if isCommandAvailable("flatpak") { // flatpak: Flatpak
-		return "flatpak"
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
 	}
-	if isCommandAvailable("nala") { // nala: Nala (alternative frontend for APT)
-		return "nala"
+	return ""
+}
+
+// runProbe runs the probe command of a package manager without any output.
+//
+// Parameters:
+//   - probe: The probe command, run through the shell or executed directly.
+//
+// Returns:
+//   - bool: True if the probe command exits successfully, false otherwise.
+func runProbe(probe Command) bool {
+	return probeCommand(probe).Run() == nil
+}
+
+// probeCommand creates the process of a command used to detect a package manager.
+//
+// Parameters:
+//   - probe: The command, run through the shell or executed directly.
+//
+// Returns:
+//   - *exec.Cmd: The process executing the argument list directly, or running the shell
+//     command with "cmd /C" on Windows and "sh -c" on other platforms.
+func probeCommand(probe Command) *exec.Cmd {
+	if len(probe.Argv) > 0 {
+		return exec.Command(probe.Argv[0], probe.Argv[1:]...)
 	}
-	if isCommandAvailable("snap") { // snap: Snap
-		return "snap"
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", probe.Shell)
 	}
-
-	return ""
+	return exec.Command("sh", "-c", probe.Shell)
 }
 
 // isCommandAvailable checks if a command is available on the system.
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// installFakeBinaries creates executables with the given names in a temporary directory
+// prepended to the PATH, so that the package managers using them are detected.
+func installFakeBinaries(t *testing.T, names ...string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake binaries are shell scripts")
+	}
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\necho 1.2.3\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// osRelease returns a function reading the given os-release, for detectDefaultPackageManager.
+func osRelease(id string, idLike ...string) func() (OSRelease, error) {
+	return func() (OSRelease, error) {
+		return OSRelease{ID: id, IDLike: idLike}, nil
+	}
+}
+
+// noOSRelease reads no os-release, as on a system without one.
+func noOSRelease() (OSRelease, error) {
+	return OSRelease{}, fs.ErrNotExist
+}
+
+func TestDetectDefaultPackageManager(t *testing.T) {
+	installFakeBinaries(t, "ipm-test-apt", "ipm-test-dnf", "ipm-test-brew", "ipm-test-snap", "ipm-test-flatpak")
+
+	detect := func(binary string, priority int, distros ...string) CommandConfig {
+		return CommandConfig{Detect: &DetectConfig{Binary: binary, Priority: priority, Distros: distros}}
+	}
+	configs := map[string]CommandConfig{
+		"apt":      detect("ipm-test-apt", 10, "debian"),
+		"dnf":      detect("ipm-test-dnf", 10, "fedora", "rhel"),
+		"brew":     detect("ipm-test-brew", 30),
+		"snap":     detect("ipm-test-snap", 20, "ubuntu"),
+		"flatpak":  detect("ipm-test-flatpak", 20),
+		"missing":  detect("ipm-test-missing", 1, "ubuntu"),
+		"never":    detect("ipm-test-apt", 0, "ubuntu"),
+		"macos":    {Detect: &DetectConfig{Binary: "ipm-test-brew", Priority: 5, OS: []string{"darwin"}}},
+		"probe":    {Detect: &DetectConfig{Binary: "ipm-test-apt", Priority: 1, Distros: []string{"ubuntu"}, Probe: &Command{Argv: []string{"false"}}}},
+		"nodetect": {},
+	}
+
+	tests := []struct {
+		name        string
+		goos        string
+		readRelease func() (OSRelease, error)
+		want        string
+	}{
+		{"ID first", "linux", osRelease("ubuntu", "debian"), "snap"},
+		{"ID_LIKE", "linux", osRelease("linuxmint", "ubuntu", "debian"), "snap"},
+		{"ID_LIKE in order", "linux", osRelease("rocky", "rhel", "centos", "fedora"), "dnf"},
+		{"second ID_LIKE", "linux", osRelease("pop", "unknown", "debian"), "apt"},
+		{"unknown distro falls back to priority", "linux", osRelease("gentoo"), "apt"},
+		{"equal priority ties are broken by name", "linux", noOSRelease, "apt"},
+		{"os filter", "darwin", noOSRelease, "macos"},
+		{"distros ignored outside linux", "freebsd", osRelease("fedora"), "apt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectDefaultPackageManager(configs, tt.goos, tt.readRelease)
+			if got.ManagerName != tt.want {
+				t.Errorf("detectDefaultPackageManager() = %q (%s), want %q", got.ManagerName, got.Reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectDefaultPackageManagerReasons(t *testing.T) {
+	installFakeBinaries(t, "ipm-test-apt")
+	configs := map[string]CommandConfig{
+		"apt": {Detect: &DetectConfig{Binary: "ipm-test-apt", Priority: 10, Distros: []string{"debian"}}},
+	}
+
+	tests := []struct {
+		name        string
+		readRelease func() (OSRelease, error)
+		want        string
+	}{
+		{"ID", osRelease("debian"), "native package manager of debian (ID in os-release)"},
+		{"ID_LIKE", osRelease("ubuntu", "debian"), "native package manager of debian (ID_LIKE in os-release)"},
+		{"ID_LIKE without ID", osRelease("", "debian"), "native package manager of debian (ID_LIKE in os-release)"},
+		{"priority", noOSRelease, "first package manager found by priority (10)"},
+		{"unreadable os-release", func() (OSRelease, error) { return OSRelease{}, errors.New("permission denied") }, "first package manager found by priority (10)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectDefaultPackageManager(configs, "linux", tt.readRelease)
+			if got.Reason != tt.want {
+				t.Errorf("detectDefaultPackageManager() reason = %q, want %q", got.Reason, tt.want)
+			}
+		})
+	}
+
+	if got := detectDefaultPackageManager(map[string]CommandConfig{}, "linux", noOSRelease); got.ManagerName != "" || got.Reason != "no package manager detected on linux" {
+		t.Errorf("detectDefaultPackageManager() = %+v, want no package manager", got)
+	}
+}
+
+func TestDetectConfigIsDetected(t *testing.T) {
+	installFakeBinaries(t, "ipm-test-apt")
+
+	tests := []struct {
+		name        string
+		managerName string
+		detect      DetectConfig
+		want        bool
+	}{
+		{"binary", "apt", DetectConfig{Binary: "ipm-test-apt"}, true},
+		{"manager name", "ipm-test-apt", DetectConfig{}, true},
+		{"missing binary", "apt", DetectConfig{Binary: "ipm-test-missing"}, false},
+		{"probe succeeds", "apt", DetectConfig{Binary: "ipm-test-apt", Probe: &Command{Argv: []string{"true"}}}, true},
+		{"probe fails", "apt", DetectConfig{Binary: "ipm-test-apt", Probe: &Command{Argv: []string{"false"}}}, false},
+		{"shell probe", "apt", DetectConfig{Binary: "ipm-test-apt", Probe: &Command{Shell: "test -n \"$PATH\""}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.detect.IsDetected(tt.managerName); got != tt.want {
+				t.Errorf("IsDetected(%q) = %v, want %v", tt.managerName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectConfigDetectVersion(t *testing.T) {
+	installFakeBinaries(t, "ipm-test-apt")
+
+	tests := []struct {
+		name    string
+		version *Command
+		want    string
+	}{
+		{"no version command", nil, ""},
+		{"first line", &Command{Argv: []string{"ipm-test-apt"}}, "1.2.3"},
+		{"first non-empty line", &Command{Shell: "printf '\\n  \\n 2.0 \\nbuild 7\\n'"}, "2.0"},
+		{"failure", &Command{Shell: "echo 1.0; exit 1"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detect := DetectConfig{Version: tt.version}
+			if got := detect.DetectVersion(); got != tt.want {
+				t.Errorf("DetectVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectConfigSupportsOS(t *testing.T) {
+	tests := []struct {
+		os   []string
+		goos string
+		want bool
+	}{
+		{nil, "linux", true},
+		{[]string{"linux", "darwin"}, "darwin", true},
+		{[]string{"linux"}, "windows", false},
+	}
+	for _, tt := range tests {
+		if got := (DetectConfig{OS: tt.os}).SupportsOS(tt.goos); got != tt.want {
+			t.Errorf("SupportsOS(%q) with %q = %v, want %v", tt.goos, tt.os, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Package utils provides utility functions for the application
+package utils
+
+import "fmt"
+
+// ConfigNotFoundError is returned when the config file of a package manager does not exist.
+//
+// Fields:
+//   - ConfigFile: The path to the missing configuration file.
+type ConfigNotFoundError struct {
+	ConfigFile string
+}
+
+// Error returns the error message.
+func (e *ConfigNotFoundError) Error() string {
+	return fmt.Sprintf("config file %s does not exist", e.ConfigFile)
+}
+
+// ManifestNotFoundError is returned when the manifest declaring the packages wanted on a
+// machine does not exist.
+//
+// Fields:
+//   - ManifestFile: The path to the missing manifest.
+type ManifestNotFoundError struct {
+	ManifestFile string
+}
+
+// Error returns the error message.
+func (e *ManifestNotFoundError) Error() string {
+	return fmt.Sprintf("manifest %s not found, run \"ipm export\" to create it or pass --file", e.ManifestFile)
+}
+
+// InvalidConfigError is returned when a config file cannot be read, decoded, encoded,
+// written or does not match the JSON schema.
+//
+// Fields:
+//   - ConfigFile: The path to the invalid configuration file.
+//   - Err: The underlying error.
+type InvalidConfigError struct {
+	ConfigFile string
+	Err        error
+}
+
+// Error returns the error message.
+func (e *InvalidConfigError) Error() string {
+	return fmt.Sprintf("invalid config %s: %v", e.ConfigFile, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *InvalidConfigError) Unwrap() error {
+	return e.Err
+}
+
+// CommandUnavailableError is returned when a command is declared as null in the config
+// of a package manager and no fallback is configured for it.
+//
+// Fields:
+//   - Command: The name of the command, e.g. "update".
+type CommandUnavailableError struct {
+	Command string
+}
+
+// Error returns the error message.
+func (e *CommandUnavailableError) Error() string {
+	return fmt.Sprintf("command %s is not available for this package manager", e.Command)
+}
+
+// CommandFailedError is returned when a package manager command cannot be started or
+// exits with a non-zero status.
+//
+// Fields:
+//   - Command: The name of the command, e.g. "install".
+//   - ExitCode: The exit status of the package manager, or -1 if it did not run.
+//   - Err: The underlying error.
+type CommandFailedError struct {
+	Command  string
+	ExitCode int
+	Err      error
+}
+
+// Error returns the error message.
+func (e *CommandFailedError) Error() string {
+	return fmt.Sprintf("failed to execute %s: %v", e.Command, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *CommandFailedError) Unwrap() error {
+	return e.Err
+}
+
+// PermissionError is returned when a command cannot be run with the privileges of ipm,
+// either because it requires root and cannot be escalated, or because the package manager
+// must not be run as root.
+//
+// Fields:
+//   - Command: The name of the command, e.g. "install".
+//   - Reason: Why the command cannot be run.
+type PermissionError struct {
+	Command string
+	Reason  string
+}
+
+// Error returns the error message.
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("cannot execute %s: %s", e.Command, e.Reason)
+}
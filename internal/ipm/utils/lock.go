@@ -0,0 +1,59 @@
+// Package utils provides utility functions for the application
+package utils
+
+// Lock represents the versions of the packages of a manifest, recorded in a lock file such as ipm.lock.
+//
+// Fields:
+//   - Packages: A map where the keys are package manager names and the values map the
+//     package ids to the versions they are pinned to.
+//
+// Example JSON structure:
+//
+//	{
+//	  "packages": {
+//	    "apt": {
+//	      "jq": "1.6-2.1"
+//	    },
+//	    "npm": {
+//	      "typescript": "5.4.5"
+//	    }
+//	  }
+//	}
+type Lock struct {
+	Packages map[string]map[string]string `json:"packages"` // Map of package manager names to package versions
+}
+
+// Version returns the version a package is pinned to.
+//
+// Parameters:
+//   - managerName: The name of the package manager.
+//   - packageName: The package id of the package manager.
+//
+// Returns:
+//   - string: The version of the package, or an empty string if it is not pinned.
+func (l Lock) Version(managerName string, packageName string) string {
+	return l.Packages[managerName][packageName]
+}
+
+// Set pins a package to a version.
+//
+// Parameters:
+//   - managerName: The name of the package manager.
+//   - packageName: The package id of the package manager.
+//   - version: The version of the package.
+//
+// Returns:
+//   - bool: True if the version of the package changed, false otherwise.
+func (l *Lock) Set(managerName string, packageName string, version string) bool {
+	if l.Version(managerName, packageName) == version {
+		return false
+	}
+	if l.Packages == nil {
+		l.Packages = make(map[string]map[string]string)
+	}
+	if l.Packages[managerName] == nil {
+		l.Packages[managerName] = make(map[string]string)
+	}
+	l.Packages[managerName][packageName] = version
+	return true
+}
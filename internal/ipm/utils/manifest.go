@@ -0,0 +1,100 @@
+// Package utils provides utility functions for the application
+package utils
+
+// Manifest represents the packages wanted on a machine, declared in a manifest file such as ipm.json.
+//
+// Fields:
+//   - Packages: The packages installed with the default package manager.
+//   - Managers: A map where the keys are package manager names and the values are the
+//     packages installed with them.
+//   - OS: A map where the keys are operating systems, as reported by runtime.GOOS, and the
+//     values are the packages only wanted on them.
+//
+// Example JSON structure:
+//
+//	{
+//	  "packages": ["git", "jq"],
+//	  "managers": {
+//	    "npm": ["typescript"]
+//	  },
+//	  "os": {
+//	    "linux": {
+//	      "managers": {
+//	        "flatpak": ["org.gimp.GIMP"]
+//	      }
+//	    }
+//	  }
+//	}
+type Manifest struct {
+	Packages []string                    `json:"packages,omitempty"` // Packages of the default package manager
+	Managers map[string][]string         `json:"managers,omitempty"` // Map of package manager names to packages
+	OS       map[string]ManifestPackages `json:"os,omitempty"`       // Map of operating systems to packages
+}
+
+// ManifestPackages represents the packages of a manifest only wanted on an operating system.
+//
+// Fields:
+//   - Packages: The packages installed with the default package manager.
+//   - Managers: A map where the keys are package manager names and the values are the
+//     packages installed with them.
+type ManifestPackages struct {
+	Packages []string            `json:"packages,omitempty"` // Packages of the default package manager
+	Managers map[string][]string `json:"managers,omitempty"` // Map of package manager names to packages
+}
+
+// HasDefaultPackages reports whether the manifest declares packages of the default package manager.
+//
+// Parameters:
+//   - goos: The operating system, as reported by runtime.GOOS.
+//
+// Returns:
+//   - bool: True if the manifest declares packages of the default package manager for all
+//     operating systems or for the given one, false otherwise.
+func (m Manifest) HasDefaultPackages(goos string) bool {
+	return len(m.Packages) > 0 || len(m.OS[goos].Packages) > 0
+}
+
+// Resolve returns the packages wanted on an operating system, grouped by package manager.
+//
+// Parameters:
+//   - goos: The operating system, as reported by runtime.GOOS.
+//   - defaultManager: The name of the package manager of the packages declared without one.
+//
+// Returns:
+//   - map[string][]string: A map where the keys are package manager names and the values are
+//     their packages, in the order they are declared and without duplicates.
+//
+// Example usage:
+//
+//	packages := manifest.Resolve(runtime.GOOS, "apt") // {"apt": ["git", "jq"], "npm": ["typescript"]}
+//
+// This function performs the following steps:
+//  1. Adds the packages declared for all operating systems.
+//  2. Adds the packages declared for the given operating system.
+//  3. Assigns the packages declared without a package manager to the default package manager.
+func (m Manifest) Resolve(goos string, defaultManager string) map[string][]string {
+	packages := make(map[string][]string)
+	seen := make(map[string]map[string]bool)
+	add := func(managerName string, names []string) {
+		if seen[managerName] == nil {
+			seen[managerName] = make(map[string]bool)
+		}
+		for _, name := range names {
+			if !seen[managerName][name] {
+				seen[managerName][name] = true
+				packages[managerName] = append(packages[managerName], name)
+			}
+		}
+	}
+
+	// Add the packages declared for all operating systems and then for the given one
+	for _, group := range []ManifestPackages{{Packages: m.Packages, Managers: m.Managers}, m.OS[goos]} {
+		if len(group.Packages) > 0 {
+			add(defaultManager, group.Packages)
+		}
+		for managerName, names := range group.Managers {
+			add(managerName, names)
+		}
+	}
+	return packages
+}
@@ -0,0 +1,104 @@
+// Package utils provides utility functions for the application
+package utils
+
+// PackageMapping maps logical package names to the package ids of each package manager.
+//
+// The keys are logical package names, and the values map package manager names to the
+// id of the package for that package manager. Package managers that are not listed use
+// the logical name as is.
+//
+// Example JSON structure:
+//
+//	{
+//	  "fd": {
+//	    "apt": "fd-find",
+//	    "winget": "sharkdp.fd"
+//	  }
+//	}
+type PackageMapping map[string]map[string]string
+
+// Resolve returns the package id of a logical package name for a package manager.
+//
+// Parameters:
+//   - managerName: The name of the package manager.
+//   - name: The logical package name, optionally pinned to a version with the "name@version" syntax.
+//
+// Returns:
+//   - string: The package id of the package manager, or the name itself if it is not mapped,
+//     followed by the version of the name if any.
+//
+// Example usage:
+//
+//	id := mapping.Resolve("apt", "fd")       // "fd-find"
+//	id := mapping.Resolve("apt", "fd@8.7.0") // "fd-find@8.7.0"
+func (m PackageMapping) Resolve(managerName string, name string) string {
+	packageName, version := SplitVersion(name)
+	id := m[packageName][managerName]
+	if id == "" {
+		return name
+	}
+	if version != "" {
+		return id + "@" + version
+	}
+	return id
+}
+
+// Mapped reports whether a logical package name has a package id for a package manager.
+//
+// Parameters:
+//   - managerName: The name of the package manager.
+//   - name: The logical package name, optionally pinned to a version with the "name@version" syntax.
+//
+// Returns:
+//   - bool: True if the mapping lists a package id of the package manager for the name.
+//
+// Example usage:
+//
+//	ok := mapping.Mapped("apt", "fd@8.7.0") // true
+//	ok := mapping.Mapped("pip", "fd")       // false
+func (m PackageMapping) Mapped(managerName string, name string) bool {
+	packageName, _ := SplitVersion(name)
+	return m[packageName][managerName] != ""
+}
+
+// ResolveAll returns the package ids of logical package names for a package manager.
+//
+// Parameters:
+//   - managerName: The name of the package manager.
+//   - names: The logical package names.
+//
+// Returns:
+//   - []string: The package id of each name, in the same order.
+//
+// Example usage:
+//
+//	ids := mapping.ResolveAll("apt", []string{"fd", "jq"}) // ["fd-find", "jq"]
+func (m PackageMapping) ResolveAll(managerName string, names []string) []string {
+	if len(names) == 0 {
+		return names
+	}
+	ids := make([]string, len(names))
+	for i, name := range names {
+		ids[i] = m.Resolve(managerName, name)
+	}
+	return ids
+}
+
+// Merge overrides the package ids of the mapping with those of another mapping.
+//
+// Parameters:
+//   - other: The mapping whose package ids take precedence, per logical name and package manager.
+//
+// Example usage:
+//
+//	mapping.Merge(userMapping)
+func (m PackageMapping) Merge(other PackageMapping) {
+	for name, ids := range other {
+		if m[name] == nil {
+			m[name] = make(map[string]string, len(ids))
+		}
+		for managerName, id := range ids {
+			m[name][managerName] = id
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestPackageMappingResolve(t *testing.T) {
+	mapping := PackageMapping{"fd": {"apt": "fd-find"}}
+	tests := []struct {
+		managerName string
+		name        string
+		want        string
+		wantMapped  bool
+	}{
+		{"apt", "fd", "fd-find", true},
+		{"apt", "fd@8.7.0", "fd-find@8.7.0", true},
+		{"pip", "fd", "fd", false},
+		{"apt", "jq@1.6", "jq@1.6", false},
+		{"npm", "@types/node@20", "@types/node@20", false},
+		{"apt", "fd@", "fd@", false},
+	}
+	for _, tt := range tests {
+		if got := mapping.Resolve(tt.managerName, tt.name); got != tt.want {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", tt.managerName, tt.name, got, tt.want)
+		}
+		if got := mapping.Mapped(tt.managerName, tt.name); got != tt.wantMapped {
+			t.Errorf("Mapped(%q, %q) = %v, want %v", tt.managerName, tt.name, got, tt.wantMapped)
+		}
+	}
+}
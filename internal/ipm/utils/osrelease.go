@@ -0,0 +1,127 @@
+// Package utils provides utility functions for the application
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// osReleaseFiles are the files identifying the Linux distribution, in the order they are read.
+var osReleaseFiles = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// OSRelease represents the Linux distribution identified by the os-release file.
+//
+// Fields:
+//   - ID: The lower-case identifier of the distribution, e.g. "ubuntu".
+//   - IDLike: The identifiers of the distributions it is derived from, closest first,
+//     e.g. ["debian"] for Ubuntu.
+type OSRelease struct {
+	ID     string   `json:"id"`
+	IDLike []string `json:"idLike,omitempty"`
+}
+
+// IDs returns the identifiers of the distribution, its own identifier first.
+//
+// Returns:
+//   - []string: The ID followed by the ID_LIKE identifiers.
+func (r OSRelease) IDs() []string {
+	if r.ID == "" {
+		return r.IDLike
+	}
+	return append([]string{r.ID}, r.IDLike...)
+}
+
+// String returns a description of the distribution, e.g. "ubuntu (like debian)".
+func (r OSRelease) String() string {
+	if len(r.IDLike) == 0 {
+		return r.ID
+	}
+	return r.ID + " (like " + strings.Join(r.IDLike, ", ") + ")"
+}
+
+// ReadOSRelease reads the os-release file of the Linux distribution.
+//
+// Returns:
+//   - OSRelease: The identifiers of the distribution.
+//   - error: An error if no os-release file can be read.
+//
+// Example usage:
+//
+//	release, err := ReadOSRelease()
+//
+// This function performs the following steps:
+//  1. Reads /etc/os-release, or /usr/lib/os-release if it does not exist.
+//  2. Parses the ID and ID_LIKE variables.
+func ReadOSRelease() (OSRelease, error) {
+	return readOSRelease(osReleaseFiles)
+}
+
+// readOSRelease reads the first os-release file that exists.
+//
+// Parameters:
+//   - files: The os-release files, in the order they are read.
+//
+// Returns:
+//   - OSRelease: The identifiers of the distribution.
+//   - error: fs.ErrNotExist if none of the files exists, or the error of the first file that
+//     exists but cannot be read.
+//
+// Example usage:
+//
+//	release, err := readOSRelease([]string{"/etc/os-release", "/usr/lib/os-release"})
+func readOSRelease(files []string) (OSRelease, error) {
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return OSRelease{}, err
+		}
+		return parseOSRelease(data), nil
+	}
+	return OSRelease{}, fs.ErrNotExist
+}
+
+// parseOSRelease parses the content of an os-release file.
+//
+// Parameters:
+//   - data: The content of the os-release file, made of shell-compatible variable assignments.
+//
+// Returns:
+//   - OSRelease: The identifiers of the distribution, lower-cased.
+//
+// Example usage:
+//
+//	release := parseOSRelease([]byte("ID=ubuntu\nID_LIKE=debian\n"))
+func parseOSRelease(data []byte) OSRelease {
+	var release OSRelease
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// Skip empty lines, comments and lines that are not assignments
+		line := strings.TrimSpace(scanner.Text())
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Remove the quotes around the value
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `'"`)
+		}
+
+		switch key {
+		case "ID":
+			release.ID = strings.ToLower(value)
+		case "ID_LIKE":
+			release.IDLike = strings.Fields(strings.ToLower(value))
+		}
+	}
+	return release
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseOSRelease(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want OSRelease
+	}{
+		{"ubuntu", "NAME=\"Ubuntu\"\nID=ubuntu\nID_LIKE=debian\n", OSRelease{ID: "ubuntu", IDLike: []string{"debian"}}},
+		{"double quotes", "ID=\"rocky\"\nID_LIKE=\"rhel centos fedora\"\n", OSRelease{ID: "rocky", IDLike: []string{"rhel", "centos", "fedora"}}},
+		{"single quotes", "ID='opensuse-tumbleweed'\nID_LIKE='opensuse suse'\n", OSRelease{ID: "opensuse-tumbleweed", IDLike: []string{"opensuse", "suse"}}},
+		{"escaped quote", `ID="my\"distro"`, OSRelease{ID: `my"distro`}},
+		{"upper case", "ID=Arch\nID_LIKE=ARCH\n", OSRelease{ID: "arch", IDLike: []string{"arch"}}},
+		{"no ID_LIKE", "ID=debian\nVERSION_ID=\"12\"\n", OSRelease{ID: "debian"}},
+		{"comments and blank lines", "# ID=fake\n\n  ID=alpine  \nnot an assignment\n", OSRelease{ID: "alpine"}},
+		{"last assignment wins", "ID=debian\nID=ubuntu\n", OSRelease{ID: "ubuntu"}},
+		{"empty", "", OSRelease{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseOSRelease([]byte(tt.data))
+			if got.ID != tt.want.ID || !slices.Equal(got.IDLike, tt.want.IDLike) {
+				t.Errorf("parseOSRelease() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOSReleaseIDs(t *testing.T) {
+	tests := []struct {
+		release OSRelease
+		want    []string
+	}{
+		{OSRelease{ID: "ubuntu", IDLike: []string{"debian"}}, []string{"ubuntu", "debian"}},
+		{OSRelease{ID: "debian"}, []string{"debian"}},
+		{OSRelease{IDLike: []string{"rhel", "fedora"}}, []string{"rhel", "fedora"}},
+		{OSRelease{}, nil},
+	}
+	for _, tt := range tests {
+		if got := tt.release.IDs(); !slices.Equal(got, tt.want) {
+			t.Errorf("%+v.IDs() = %q, want %q", tt.release, got, tt.want)
+		}
+	}
+}
+
+func TestReadOSRelease(t *testing.T) {
+	dir := t.TempDir()
+	etc := filepath.Join(dir, "etc-os-release")
+	usrLib := filepath.Join(dir, "usr-lib-os-release")
+	missing := filepath.Join(dir, "missing")
+	if err := os.WriteFile(etc, []byte("ID=fedora\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(usrLib, []byte("ID=arch\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"first file", []string{etc, usrLib}, "fedora"},
+		{"fallback", []string{missing, usrLib}, "arch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release, err := readOSRelease(tt.files)
+			if err != nil {
+				t.Fatalf("readOSRelease() error = %v", err)
+			}
+			if release.ID != tt.want {
+				t.Errorf("readOSRelease() ID = %q, want %q", release.ID, tt.want)
+			}
+		})
+	}
+
+	if _, err := readOSRelease([]string{missing}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("readOSRelease() error = %v, want fs.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,36 @@
+// Package utils provides utility functions for the application
+package utils
+
+// Programs used to run the commands that require root, set with the escalation setting.
+const (
+	// EscalationSudo runs the commands with sudo.
+	EscalationSudo = "sudo"
+	// EscalationDoas runs the commands with doas.
+	EscalationDoas = "doas"
+	// EscalationPkexec runs the commands with pkexec.
+	EscalationPkexec = "pkexec"
+	// EscalationNone runs the commands without escalating their privileges.
+	EscalationNone = "none"
+)
+
+// EscalationPrograms lists the valid values of the escalation setting.
+var EscalationPrograms = []string{EscalationSudo, EscalationDoas, EscalationPkexec, EscalationNone}
+
+// Settings represents the settings of ipm itself, stored in its settings file.
+//
+// Fields:
+//   - Default: The name of the package manager used by the default commands, such as
+//     "ipm install", or an empty string to detect it based on the OS.
+//   - Escalation: The program used to run the commands that require root, one of the
+//     Escalation constants, or an empty string to use sudo or doas, whichever is installed.
+//
+// Example JSON structure:
+//
+//	{
+//	  "default": "apt",
+//	  "escalation": "doas"
+//	}
+type Settings struct {
+	Default    string `json:"default,omitempty"`
+	Escalation string `json:"escalation,omitempty"`
+}
@@ -0,0 +1,28 @@
+// Package utils provides utility functions for the application
+package utils
+
+import "strings"
+
+// SplitVersion splits a package pinned with the "name@version" syntax into its name and version.
+//
+// The package is split on its last "@", unless it is the first character, so that scoped
+// packages such as "@types/node" are not mistaken for a version.
+//
+// Parameters:
+//   - param: The package passed on the command line, e.g. "jq@1.6" or "@types/node@20.1.0".
+//
+// Returns:
+//   - string: The name of the package, or the parameter itself if it has no version.
+//   - string: The version of the package, or an empty string if it has no version.
+//
+// Example usage:
+//
+//	name, version := utils.SplitVersion("@types/node@20.1.0") // "@types/node", "20.1.0"
+//	name, version := utils.SplitVersion("jq")                 // "jq", ""
+func SplitVersion(param string) (string, string) {
+	i := strings.LastIndex(param, "@")
+	if i <= 0 || i == len(param)-1 {
+		return param, ""
+	}
+	return param[:i], param[i+1:]
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "testing"
+
+func TestSplitVersion(t *testing.T) {
+	tests := []struct {
+		param       string
+		wantName    string
+		wantVersion string
+	}{
+		{"jq", "jq", ""},
+		{"jq@1.6", "jq", "1.6"},
+		{"@types/node", "@types/node", ""},
+		{"@types/node@20", "@types/node", "20"},
+		{"@types/node@20.1.0", "@types/node", "20.1.0"},
+		{"jq@", "jq@", ""},
+		{"@types/node@", "@types/node@", ""},
+		{"", "", ""},
+		{"@", "@", ""},
+		{"@1.0", "@1.0", ""},
+		{"a@b@c", "a@b", "c"},
+	}
+	for _, tt := range tests {
+		name, version := SplitVersion(tt.param)
+		if name != tt.wantName || version != tt.wantVersion {
+			t.Errorf("SplitVersion(%q) = %q, %q, want %q, %q", tt.param, name, version, tt.wantName, tt.wantVersion)
+		}
+	}
+}
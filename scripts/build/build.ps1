@@ -40,10 +40,6 @@ function Invoke-BinaryBuild {
         exit 1
     }
 
-    # Copy the config directory to the output directory
-    Write-Host "Copying config directory to $outputDir..."
-    Copy-Item -Recurse -Path "config" -Destination "$outputDir/config"
-
     # Create the archive directory if it does not exist
     if (-not (Test-Path -Path $archiveDir)) {
         [void](New-Item -ItemType Directory -Path $archiveDir)
@@ -39,10 +39,6 @@ invoke_binary_build() {
     exit 1
   fi
 
-  # Copy the config directory to the output directory
-  echo "Copying config directory to ${OUTPUT_DIR}..."
-  cp -r config "${OUTPUT_DIR}/config"
-
   # Create the archive directory if it does not exist
   mkdir -p "${ARCHIVE_DIR}"
 